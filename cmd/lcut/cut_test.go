@@ -32,6 +32,18 @@ func TestCutFields(t *testing.T) {
 			true,
 			"a=b",
 		},
+		{
+			"a=b c=d e=f",
+			[]string{"a", "c"},
+			false,
+			"e=f",
+		},
+		{
+			"a=b c=d e=f",
+			[]string{"e", "a"},
+			true,
+			"a=b e=f",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -41,3 +53,51 @@ func TestCutFields(t *testing.T) {
 		require.Equal(t, tc.exp, pairs.Format())
 	}
 }
+
+func TestCutFieldsPreserve(t *testing.T) {
+	testCases := []struct {
+		input   string
+		cut     []string
+		reverse bool
+		exp     string
+	}{
+		{
+			`city="Lyon"  name=Vincent   msg="foo bar" foo=bar`,
+			[]string{"foo"},
+			false,
+			`city="Lyon"  name=Vincent   msg="foo bar"`,
+		},
+		{
+			`city="Lyon"  name=Vincent   msg="foo bar" foo=bar`,
+			[]string{"city"},
+			false,
+			`name=Vincent   msg="foo bar" foo=bar`,
+		},
+		{
+			`a="b"c=d e=f`,
+			[]string{"e"},
+			false,
+			`a="b"c=d`,
+		},
+		{
+			`a=b c="d"e=f`,
+			[]string{"c"},
+			false,
+			`a=b e=f`,
+		},
+		{
+			`a=1 debug  msg="été"`,
+			[]string{"a"},
+			false,
+			`debug  msg="été"`,
+		},
+	}
+
+	parser := logfmt.PairParser{KeepRaw: true}
+	for _, tc := range testCases {
+		pairs := parser.Split(tc.input)
+		f := cutFields(tc.cut)
+		pairs = f.CutFrom(tc.reverse, pairs)
+		require.Equal(t, tc.exp, pairs.FormatPreserve())
+	}
+}
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"bufio"
 	"os"
 	"strings"
 
@@ -32,19 +31,10 @@ func (f cutFields) CutFrom(reverse bool, pairs logfmt.Pairs) logfmt.Pairs {
 		return pairs
 	}
 
-	res := pairs[:0]
-	for _, pair := range pairs {
-		for _, field := range f {
-			ok := !reverse && pair.Key != field
-			reverseOK := reverse && pair.Key == field
-
-			if ok || reverseOK {
-				res = append(res, pair)
-			}
-		}
+	if reverse {
+		return pairs.Keep(f...)
 	}
-
-	return res
+	return pairs.Drop(f...)
 }
 
 func runMain(cmd *cobra.Command, args []string) error {
@@ -57,20 +47,29 @@ func runMain(cmd *cobra.Command, args []string) error {
 	inputs := internal.GetInputs(flInput)
 
 	buf := make([]byte, 0, 4096)
+	window := internal.NewTimeWindow()
+
 	for _, input := range inputs {
-		scanner := bufio.NewScanner(input.Reader)
-		scanner.Buffer(make([]byte, int(flags.MaxLineSize)/2), int(flags.MaxLineSize))
-		for scanner.Scan() {
-			line := scanner.Text()
-			pairs := logfmt.Split(line)
+		dec := internal.NewDecoder(input.Reader)
+		// Keep the untouched pairs as they are in the input
+		dec.Parser.KeepRaw = true
+
+		for dec.Next() {
+			in, done := window.Check(dec)
+			if done {
+				break
+			}
+			if !in {
+				continue
+			}
 
-			pairs = fields.CutFrom(flReverse, pairs)
+			pairs := fields.CutFrom(flReverse, dec.Pairs())
 
 			if len(pairs) <= 0 {
 				continue
 			}
 
-			buf = pairs.AppendFormat(buf)
+			buf = pairs.AppendFormatPreserve(buf)
 			buf = append(buf, '\n')
 
 			_, err := os.Stdout.Write(buf)
@@ -80,7 +79,7 @@ func runMain(cmd *cobra.Command, args []string) error {
 
 			buf = buf[:0]
 		}
-		if err := scanner.Err(); err != nil {
+		if err := dec.Err(); err != nil {
 			return err
 		}
 	}
@@ -112,7 +111,7 @@ func init() {
 
 	fs.BoolVarP(&flReverse, "reverse", "v", false, "Reverse cut: keep only the fields provided")
 	fs.VarP(&flInput, "input", "i", "Use these input files instead of stdin")
-	fs.Var(&flags.MaxLineSize, "max-line-size", "Max size in bytes of a line")
+	flags.AddRecordFlags(fs)
 	fs.StringVar(&flags.CPUProfile, "cpu-profile", "", "Writes a CPU profile at `cpu-profile` after execution")
 	fs.StringVar(&flags.MemProfile, "mem-profile", "", "Writes a memory profile at `mem-profile` after execution")
 }
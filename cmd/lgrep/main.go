@@ -1,62 +1,122 @@
 package main
 
 import (
-	"bufio"
-	"io"
 	"os"
-	"reflect"
-	"unsafe"
 
 	"github.com/spf13/cobra"
+	"github.com/vrischmann/logfmt"
 	"github.com/vrischmann/logfmt/internal"
 	"github.com/vrischmann/logfmt/internal/flags"
 	"github.com/vrischmann/logfmt/lgrep"
 )
 
+// matcher matches lines either with the queries given as arguments or with the expression given with --expr.
+type matcher interface {
+	MatchBytes(line []byte) bool
+	MatchPairs(pairs logfmt.Pairs) bool
+}
+
+type queriesMatcher struct {
+	queries lgrep.Queries
+	opt     *lgrep.QueryOption
+}
+
+func (m *queriesMatcher) MatchBytes(line []byte) bool {
+	return m.queries.MatchBytes(line, m.opt)
+}
+
+func (m *queriesMatcher) MatchPairs(pairs logfmt.Pairs) bool {
+	return m.queries.MatchPairs(pairs, m.opt)
+}
+
+type exprMatcher struct {
+	expr    *lgrep.Expr
+	reverse bool
+}
+
+func (m *exprMatcher) MatchBytes(line []byte) bool {
+	return m.expr.MatchBytes(line) != m.reverse
+}
+
+func (m *exprMatcher) MatchPairs(pairs logfmt.Pairs) bool {
+	return m.expr.MatchPairs(pairs) != m.reverse
+}
+
 func runMain(cmd *cobra.Command, args []string) error {
 	stopProfiling := internal.StartProfiling(flags.CPUProfile, flags.MemProfile)
 	defer stopProfiling()
 
 	//
 
-	qs := lgrep.ExtractQueries(args)
-	args = args[len(qs):]
+	var m matcher
+
+	if flExpr != "" {
+		expr, err := lgrep.ParseExpr(flExpr)
+		if err != nil {
+			return err
+		}
+		expr.SetDuplicatePolicy(logfmt.DuplicatePolicy(flags.Duplicates))
+
+		m = &exprMatcher{expr: expr, reverse: flReverse}
+	} else {
+		qs, err := lgrep.ExtractQueries(args)
+		if err != nil {
+			return err
+		}
+		args = args[len(qs):]
+
+		qs.SetDuplicatePolicy(logfmt.DuplicatePolicy(flags.Duplicates))
+
+		m = &queriesMatcher{
+			queries: qs,
+			opt: &lgrep.QueryOption{
+				Or:      flOr,
+				Reverse: flReverse,
+			},
+		}
+	}
 
 	//
 
 	inputs := internal.GetInputs(args)
 
-	qryOpt := &lgrep.QueryOption{
-		Or:      flOr,
-		Reverse: flReverse,
-	}
+	buf := make([]byte, 0, 4096)
+	window := internal.NewTimeWindow()
 
 	for _, input := range inputs {
-		scanner := bufio.NewScanner(input.Reader)
-		scanner.Buffer(make([]byte, int(flags.MaxLineSize)/2), int(flags.MaxLineSize))
-
-		strHeader := new(reflect.StringHeader)
+		dec := internal.NewDecoder(input.Reader)
 
-		for scanner.Scan() {
-			data := scanner.Bytes()
-			if len(data) <= 0 {
+		for dec.Next() {
+			in, done := window.Check(dec)
+			if done {
+				break
+			}
+			if !in {
 				continue
 			}
 
-			strHeader.Data = uintptr(unsafe.Pointer(&data[0]))
-			strHeader.Len = len(data)
+			line := dec.Bytes()
 
-			line := *(*string)(unsafe.Pointer(strHeader))
+			var matched bool
+			if dec.Prefix != nil || dec.Continuation != nil {
+				// The header and the continuation lines must be parsed into pairs to match them
+				matched = m.MatchPairs(dec.Pairs())
+			} else {
+				matched = m.MatchBytes(line)
+			}
 
-			if qs.Match(line, qryOpt) {
+			if matched {
+				buf = buf[:0]
 				if flWithFilename {
-					io.WriteString(os.Stdout, input.Name+": "+line+"\n")
-				} else {
-					io.WriteString(os.Stdout, line+"\n")
+					buf = append(buf, input.Name+": "...)
 				}
+				buf = append(buf, line...)
+				buf = append(buf, '\n')
+
+				os.Stdout.Write(buf)
 			}
 		}
-		if err := scanner.Err(); err != nil {
+		if err := dec.Err(); err != nil {
 			return err
 		}
 	}
@@ -81,18 +141,48 @@ QUERY must be in one of these form:
     city=Lyon                      for a strict match. Will only match lines which have the "city" key with the value Lyon.
     city~New                       for a fuzzy match. Will match lines which have the "city" key with any value contaning New.
     city=~(Paris|Lyon|San [a-z]+)  for a regexp match. Will match lines which have the "city" key and for which the regexp matches the value.
-
-You can also trick lgrep to test for presence of a key by using a fuzzy match operator with no value to match:
-    city~                          Will match lines which have the "city" key with any value (because any value contains the empty string).
-
-You can have multiple queries. By default it will work as an AND, you can treat them as a OR with the --or option.`,
-		Args: cobra.MinimumNArgs(1),
+    status>=500                    for a numeric comparison, with <, <=, > or >=. Will match lines which have the "status" key
+                                   with a number greater than or equal to 500. Values which are not numbers never match.
+    elapsed>2s                     for a duration comparison. Will match lines which have the "elapsed" key with a duration
+                                   longer than 2 seconds, like 2.5s or 1m.
+    time>=2026-10-17T10:00:00Z     for a time comparison. Will match lines which have the "time" key with a RFC3339 time or
+                                   a number of seconds or milliseconds since the Unix epoch after 10am UTC on October 17th 2026.
+    level!=info                    for a negated match. Will match lines which have the "level" key with any other value.
+                                   If both values are numbers they are compared as numbers, so status!=500 doesn't match status=500.0.
+
+You can also test for the presence or the absence of a key:
+    city?                          Will match lines which have the "city" key, with any value or as a bare key.
+    !city                          Will match lines which don't have the "city" key.
+    city=""                        Will match lines which have the "city" key with an empty value, like city= or a bare city.
+
+Queries with <, >, ?, ! or quotes must be quoted for the shell: lgrep 'bytes>1048576' '!city'.
+
+You can have multiple queries. By default it will work as an AND, you can treat them as a OR with the --or option.
+
+For anything more complex give a boolean expression with --expr/-e, all the arguments are then files.
+Queries are combined with not, and, or (from the highest to the lowest precedence) and grouped with parentheses:
+    lgrep -e '(status=500 or status=503) and not path~health'   Will match the errors not coming from the health check
+    lgrep -e 'msg="connection refused" or level=error'         Values containing spaces must be quoted
+
+Lines with a syslog, CRI or docker header can be searched with --prefix, the header fields are then matched like other keys:
+    lgrep --prefix syslog app=api level=error   Will match lines like "Oct 17 12:00:01 host api[123]: level=error msg=..."
+
+Multi-line records like stack traces are kept whole with --multiline-indent or --record-start, the continuation lines
+are the value of the "stack" key (see --continuation-key):
+    lgrep --record-start '^time=' stack~NilPointer   Will print the records, and their stack trace, where it contains NilPointer`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if flExpr != "" {
+				return nil
+			}
+			return cobra.MinimumNArgs(1)(cmd, args)
+		},
 		RunE: runMain,
 	}
 
 	flReverse      bool
 	flWithFilename bool
 	flOr           bool
+	flExpr         string
 )
 
 func init() {
@@ -101,7 +191,8 @@ func init() {
 	fs.BoolVarP(&flReverse, "reverse", "v", false, "Reverse matches")
 	fs.BoolVarP(&flWithFilename, "with-filename", "H", false, "Display the filename")
 	fs.BoolVarP(&flOr, "or", "o", false, "Treat multiple queries as a OR instead of a AND")
-	fs.Var(&flags.MaxLineSize, "max-line-size", "Max size in bytes of a line")
+	fs.StringVarP(&flExpr, "expr", "e", "", "Match lines with a boolean expression of queries instead of the query arguments")
+	flags.AddRecordFlags(fs)
 	fs.StringVar(&flags.CPUProfile, "cpu-profile", "", "Writes a CPU profile at `cpu-profile` after execution")
 	fs.StringVar(&flags.MemProfile, "mem-profile", "", "Writes a memory profile at `mem-profile` after execution")
 }
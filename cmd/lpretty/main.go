@@ -1,7 +1,6 @@
 package main
 
 import (
-	"bufio"
 	"fmt"
 	"os"
 
@@ -16,7 +15,7 @@ const transformOperator = "::"
 
 func extractTransform(args []string) (transform, []string) {
 	if flMerge {
-		return newMergeToJSONTransform(flAll, args), nil
+		return newMergeToJSONTransform(flAll, flExpand, flTyped, args), nil
 	}
 	if flNewline {
 		return &dummyTransform{}, nil
@@ -51,12 +50,20 @@ func runMain(cmd *cobra.Command, args []string) error {
 	inputs := internal.GetInputs(args)
 
 	buf := make([]byte, 0, 4096)
+	window := internal.NewTimeWindow()
+
 	for _, input := range inputs {
-		scanner := bufio.NewScanner(input.Reader)
-		scanner.Buffer(make([]byte, int(flags.MaxLineSize)/2), int(flags.MaxLineSize))
-		for scanner.Scan() {
-			line := scanner.Text()
-			pairs := logfmt.Split(line)
+		dec := internal.NewDecoder(input.Reader)
+		for dec.Next() {
+			in, done := window.Check(dec)
+			if done {
+				break
+			}
+			if !in {
+				continue
+			}
+
+			pairs := dec.Pairs()
 
 			//
 
@@ -75,8 +82,10 @@ func runMain(cmd *cobra.Command, args []string) error {
 				case flNewline:
 					for _, pair := range v {
 						buf = append(buf, pair.Key...)
-						buf = append(buf, '=')
-						buf = append(buf, pair.Value...)
+						if !pair.Bare {
+							buf = append(buf, '=')
+							buf = append(buf, pair.Value...)
+						}
 						buf = append(buf, '\n')
 					}
 
@@ -107,7 +116,7 @@ func runMain(cmd *cobra.Command, args []string) error {
 
 			buf = buf[:0]
 		}
-		if err := scanner.Err(); err != nil {
+		if err := dec.Err(); err != nil {
 			return err
 		}
 	}
@@ -212,6 +221,37 @@ Examples:
 		"name": "vincent"
 	}
 
+* With --expand/-E dotted keys are expanded into nested objects, keys like items.0 and items.1 become arrays
+
+	$ echo 'http.method=GET http.path=/x tags.0=a tags.1=b' > /tmp/logfmt
+	$ cat /tmp/logfmt | lpretty -M -E --all
+	{
+		"http": {
+			"method": "GET",
+			"path": "/x"
+		},
+		"tags": [
+			"a",
+			"b"
+		]
+	}
+
+* With --typed/-T the type of each value is inferred: numbers, booleans and JSON values are not quoted and empty values are null
+
+	$ echo 'id=10 name=vincent admin elapsed=1.5 data="[1,2]" email=' > /tmp/logfmt
+	$ cat /tmp/logfmt | lpretty -M -T --all
+	{
+		"admin": true,
+		"data": [
+			1,
+			2
+		],
+		"elapsed": 1.5,
+		"email": null,
+		"id": 10,
+		"name": "vincent"
+	}
+
 Finally there's a third mode which strips the key of the first pair and only prints its value.
 This is useful when you pipe lpretty to the output of lcut.
 
@@ -227,14 +267,18 @@ Examples:
 	flNewline  bool
 	flStripKey bool
 	flAll      bool
+	flExpand   bool
+	flTyped    bool
 )
 
 func init() {
 	fs := rootCmd.Flags()
 
-	fs.Var(&flags.MaxLineSize, "max-line-size", "Max size in bytes of a line")
+	flags.AddRecordFlags(fs)
 	fs.BoolVarP(&flMerge, "merge", "M", false, "Merge all fields in a single JSON object")
 	fs.BoolVarP(&flNewline, "newline", "N", false, "Print all fields into its own line")
 	fs.BoolVarP(&flStripKey, "strip-key", "S", false, "Strip the key of the first pair and only print the value")
 	fs.BoolVar(&flAll, "all", false, "When merging in a single JSON object include all fields, not just the one described in the arguments")
+	fs.BoolVarP(&flExpand, "expand", "E", false, "When merging in a single JSON object expand dotted keys like a.b=c into nested objects")
+	fs.BoolVarP(&flTyped, "typed", "T", false, "When merging in a single JSON object output numbers, booleans, nulls and JSON values with their type instead of strings")
 }
@@ -85,14 +85,18 @@ loop:
 }
 
 type mergeToJSONTransform struct {
-	all  bool
-	keys map[string]string
+	all    bool
+	expand bool
+	typed  bool
+	keys   map[string]string
 }
 
-func newMergeToJSONTransform(all bool, args []string) *mergeToJSONTransform {
+func newMergeToJSONTransform(all, expand, typed bool, args []string) *mergeToJSONTransform {
 	ret := &mergeToJSONTransform{
-		all:  all,
-		keys: make(map[string]string),
+		all:    all,
+		expand: expand,
+		typed:  typed,
+		keys:   make(map[string]string),
 	}
 
 	for _, arg := range args {
@@ -115,20 +119,54 @@ func (t *mergeToJSONTransform) Apply(pairs logfmt.Pairs) interface{} {
 		if !ok && !t.all {
 			continue
 		}
+		if _, ok := obj[pair.Key]; ok {
+			// Like logfmt.Pairs.Get the first occurrence of a key wins
+			continue
+		}
 
 		switch typ {
 		case "json":
 			obj[pair.Key] = json.RawMessage(pair.Value)
 		default:
-			obj[pair.Key] = pair.Value
+			if t.typed {
+				obj[pair.Key] = typedValue(pair)
+			} else {
+				obj[pair.Key] = pair.Value
+			}
 		}
 	}
 
+	if t.expand {
+		obj = logfmt.ExpandMap(obj)
+	}
+
 	data, _ := json.MarshalIndent(obj, "", "  ")
 
 	return data
 }
 
+// typedValue returns the value of the pair as a JSON value of its inferred type.
+// Durations and times are kept as strings.
+func typedValue(pair logfmt.Pair) interface{} {
+	switch logfmt.InferType(pair) {
+	case logfmt.TypeNull:
+		return nil
+	case logfmt.TypeBool:
+		return pair.Bare || pair.Value == "true"
+	case logfmt.TypeInt, logfmt.TypeFloat:
+		// Some numbers like +1 are not valid JSON numbers
+		if json.Valid([]byte(pair.Value)) {
+			return json.Number(pair.Value)
+		}
+		f, _ := pair.Float()
+		return f
+	case logfmt.TypeJSON:
+		return json.RawMessage(pair.Value)
+	default:
+		return pair.Value
+	}
+}
+
 type dummyTransform struct{}
 
 func (t *dummyTransform) Apply(pairs logfmt.Pairs) interface{} {
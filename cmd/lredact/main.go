@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vrischmann/logfmt/internal"
+	"github.com/vrischmann/logfmt/internal/flags"
+	"github.com/vrischmann/logfmt/redact"
+)
+
+const hashKeyEnv = "LREDACT_HASH_KEY"
+
+func buildRedactor() (*redact.Redactor, error) {
+	r := &redact.Redactor{
+		Mask: flMask,
+	}
+
+	for _, s := range flRules {
+		rule, err := redact.ParseRule(s)
+		if err != nil {
+			return nil, err
+		}
+		r.Rules = append(r.Rules, rule)
+	}
+	if len(r.Rules) == 0 || flDefaults {
+		r.Rules = append(r.Rules, redact.DefaultRules()...)
+	}
+
+	switch {
+	case flHashKeyFile != "":
+		data, err := os.ReadFile(flHashKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		r.HashKey = bytes.TrimSpace(data)
+	default:
+		r.HashKey = []byte(os.Getenv(hashKeyEnv))
+	}
+
+	if len(r.HashKey) == 0 {
+		for _, rule := range r.Rules {
+			if rule.Action == redact.Hash {
+				return nil, errors.New("the hash action needs a key, set it with --hash-key-file or $" + hashKeyEnv)
+			}
+		}
+	}
+
+	return r, nil
+}
+
+func runMain(cmd *cobra.Command, args []string) error {
+	stopProfiling := internal.StartProfiling(flags.CPUProfile, flags.MemProfile)
+	defer stopProfiling()
+
+	redactor, err := buildRedactor()
+	if err != nil {
+		return err
+	}
+
+	//
+
+	inputs := internal.GetInputs(args)
+
+	buf := make([]byte, 0, 4096)
+	window := internal.NewTimeWindow()
+
+	for _, input := range inputs {
+		dec := internal.NewDecoder(input.Reader)
+		// Keep the untouched pairs as they are in the input
+		dec.Parser.KeepRaw = true
+
+		for dec.Next() {
+			in, done := window.Check(dec)
+			if done {
+				break
+			}
+			if !in {
+				continue
+			}
+
+			pairs := redactor.Redact(dec.Pairs())
+
+			buf = pairs.AppendFormatPreserve(buf)
+			buf = append(buf, '\n')
+
+			_, err := os.Stdout.Write(buf)
+			if err != nil {
+				return err
+			}
+
+			buf = buf[:0]
+		}
+		if err := dec.Err(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func main() {
+	rootCmd.Execute()
+}
+
+var (
+	rootCmd = &cobra.Command{
+		Use:   "lredact [file]",
+		Short: `redact secrets and personal data from each "file"`,
+		Long: `redact secrets and personal data from each "file".
+
+Multiple files are allowed. If no files, read from stdin.
+
+The data to redact is described by rules given with --rule/-r, in the form "action:condition,condition...":
+
+    mask:key=password              replace the value of the "password" key with [REDACTED].
+    drop:key=*token*               remove the pairs whose key contains "token".
+    hash:key=user_*                replace the values of keys starting with "user_" with a keyed hash.
+    mask:detect=email              replace the email addresses found in any value.
+    hash:key=msg,detect=ipv4       replace the IPv4 addresses found in the "msg" value with a keyed hash.
+    mask:value=[0-9]{6}            replace the parts of any value matching the regexp.
+
+The actions are:
+    mask    replace the data with [REDACTED] or the text given with --mask.
+    hash    replace the data with a pseudonym: the same data always gives the same pseudonym, so lines can still
+            be correlated, but it can't be reversed without the key given with --hash-key-file or $` + hashKeyEnv + `.
+    drop    remove the whole pair.
+
+The conditions are:
+    key=GLOB       the key matches the glob ignoring case, like "*token*".
+    detect=NAME    the value contains data found by a built-in detector: ` + strings.Join(redact.DetectorNames(), ", ") + `.
+    value=REGEXP   the value matches the regexp. This must be the last condition.
+
+With only a key condition the whole value is redacted, otherwise only the data found in the value is.
+The rules without a key condition also search the keys, and the free text made of the words which are not key=value
+pairs, like "logged in from 10.1.2.3".
+
+Without rules the default rules are used: they mask the values of keys like password, secret or token and everything
+found by the built-in detectors. Use --defaults to add them to your own rules.
+
+The pairs which are not redacted are written exactly as they are in the input, quoting and spacing included. Only the
+malformed pairs whose text can't be parsed without loss, like a quoted value with an invalid escape sequence, are
+written from their parsed value like the redacted pairs.`,
+		RunE: runMain,
+	}
+
+	flRules       []string
+	flDefaults    bool
+	flMask        string
+	flHashKeyFile string
+)
+
+func init() {
+	fs := rootCmd.Flags()
+
+	fs.StringArrayVarP(&flRules, "rule", "r", nil, "Redaction rule, can be repeated")
+	fs.BoolVar(&flDefaults, "defaults", false, "Apply the default rules after the rules given with --rule")
+	fs.StringVar(&flMask, "mask", redact.DefaultMask, "Text replacing the data redacted by the mask action")
+	fs.StringVar(&flHashKeyFile, "hash-key-file", "", "Read the key of the hash action from this file instead of $"+hashKeyEnv)
+	flags.AddRecordFlags(fs)
+	fs.StringVar(&flags.CPUProfile, "cpu-profile", "", "Writes a CPU profile at `cpu-profile` after execution")
+	fs.StringVar(&flags.MemProfile, "mem-profile", "", "Writes a memory profile at `mem-profile` after execution")
+}
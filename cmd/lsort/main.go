@@ -1,11 +1,9 @@
 package main
 
 import (
-	"bufio"
-	"log"
+	"fmt"
 	"os"
 	"sort"
-	"strconv"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -17,6 +15,10 @@ import (
 type sortElement struct {
 	line  string
 	field string
+
+	number   float64
+	duration time.Duration
+	time     time.Time
 }
 
 type sortAlphabetical []sortElement
@@ -27,65 +29,59 @@ func (s sortAlphabetical) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
 
 type sortNumerical []sortElement
 
-func (s sortNumerical) Len() int { return len(s) }
-func (s sortNumerical) Less(i, j int) bool {
-	a, err := strconv.ParseInt(s[i].field, 10, 64)
-	if err != nil {
-		log.Fatalf("field %q is invalid for a numerical sort", s[i].field)
-	}
-
-	b, err := strconv.ParseInt(s[j].field, 10, 64)
-	if err != nil {
-		log.Fatalf("field %q is invalid for a numerical sort", s[j].field)
-	}
-
-	return a < b
-}
-func (s sortNumerical) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s sortNumerical) Len() int           { return len(s) }
+func (s sortNumerical) Less(i, j int) bool { return s[i].number < s[j].number }
+func (s sortNumerical) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
 
 type sortByDuration []sortElement
 
-func (s sortByDuration) Len() int { return len(s) }
-func (s sortByDuration) Less(i, j int) bool {
-	a, err := time.ParseDuration(s[i].field)
-	if err != nil {
-		log.Fatalf("field %q is invalid for a duration sort", s[i].field)
-	}
-
-	b, err := time.ParseDuration(s[j].field)
-	if err != nil {
-		log.Fatalf("field %q is invalid for a duration sort", s[j].field)
-	}
-
-	return a < b
-}
-func (s sortByDuration) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s sortByDuration) Len() int           { return len(s) }
+func (s sortByDuration) Less(i, j int) bool { return s[i].duration < s[j].duration }
+func (s sortByDuration) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
 
 type sortByTime []sortElement
 
-func (s sortByTime) Len() int { return len(s) }
-func (s sortByTime) Less(i, j int) bool {
-	a, err := time.Parse(time.RFC3339, s[i].field)
-	if err != nil {
-		log.Fatalf("field %q is invalid for a duration sort", s[i].field)
-	}
+func (s sortByTime) Len() int           { return len(s) }
+func (s sortByTime) Less(i, j int) bool { return s[i].time.Before(s[j].time) }
+func (s sortByTime) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
 
-	b, err := time.Parse(time.RFC3339, s[j].field)
-	if err != nil {
-		log.Fatalf("field %q is invalid for a duration sort", s[j].field)
+// sortType returns the type of sort requested with the flags.
+func sortType() logfmt.ValueType {
+	switch {
+	case flNumericSort:
+		return logfmt.TypeFloat
+	case flDurationSort:
+		return logfmt.TypeDuration
+	case flTimeSort:
+		return logfmt.TypeTime
+	default:
+		return logfmt.TypeString
 	}
-
-	return a.Before(b)
 }
-func (s sortByTime) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
 
-func getFromPairs(pairs logfmt.Pairs, key string) string {
-	for _, pair := range pairs {
-		if pair.Key == key {
-			return pair.Value
+// parseField parses the value of the field depending on the type of sort.
+func parseField(elem *sortElement, pair logfmt.Pair, typ logfmt.ValueType) error {
+	var err error
+
+	switch typ {
+	case logfmt.TypeInt, logfmt.TypeFloat:
+		elem.number, err = pair.Float()
+		if err != nil {
+			return fmt.Errorf("invalid value for a numerical sort: %w", err)
+		}
+	case logfmt.TypeDuration:
+		elem.duration, err = pair.Duration()
+		if err != nil {
+			return fmt.Errorf("invalid value for a duration sort: %w", err)
+		}
+	case logfmt.TypeTime:
+		elem.time, err = pair.Time(time.RFC3339Nano)
+		if err != nil {
+			return fmt.Errorf("invalid value for a time sort: %w", err)
 		}
 	}
-	return ""
+
+	return nil
 }
 
 func runMain(cmd *cobra.Command, args []string) error {
@@ -99,41 +95,68 @@ func runMain(cmd *cobra.Command, args []string) error {
 
 	lines := make([]sortElement, 0, 8192)
 
+	typ := sortType()
+
+	// In auto mode the type of the field is inferred from all its values, which are parsed once all lines are read.
+	var schema logfmt.Schema
+
+	window := internal.NewTimeWindow()
+
 	for _, input := range inputs {
-		scanner := bufio.NewScanner(input.Reader)
-		scanner.Buffer(make([]byte, int(flags.MaxLineSize)/2), int(flags.MaxLineSize))
-		for scanner.Scan() {
-			line := scanner.Text()
-			pairs := logfmt.Split(line)
+		dec := internal.NewDecoder(input.Reader)
+		for dec.Next() {
+			in, done := window.Check(dec)
+			if done {
+				break
+			}
+			if !in {
+				continue
+			}
 
+			pairs := dec.Pairs()
 			if len(pairs) <= 0 {
 				continue
 			}
 
-			val := getFromPairs(pairs, field)
-			if val == "" {
+			idx := pairs.Index(field)
+			if idx == -1 || pairs[idx].Value == "" {
 				continue
 			}
 
-			lines = append(lines, sortElement{
-				line:  line,
-				field: val,
-			})
+			elem := sortElement{
+				line:  dec.Text(),
+				field: pairs[idx].Value,
+			}
+			if flAutoSort {
+				schema.Add(pairs[idx : idx+1])
+			} else if err := parseField(&elem, pairs[idx], typ); err != nil {
+				return fmt.Errorf("%s:%d: %w", input.Name, dec.Line(), err)
+			}
+
+			lines = append(lines, elem)
 		}
-		if err := scanner.Err(); err != nil {
+		if err := dec.Err(); err != nil {
 			return err
 		}
 	}
 
 	//
 
+	if f := schema.Field(field); flAutoSort && f != nil {
+		typ = f.Type()
+		for i := range lines {
+			// Every non-null value has the inferred type, null values sort as the zero value
+			parseField(&lines[i], logfmt.Pair{Key: field, Value: lines[i].field}, typ)
+		}
+	}
+
 	var sl sort.Interface
-	switch {
-	case flNumericSort:
+	switch typ {
+	case logfmt.TypeInt, logfmt.TypeFloat:
 		sl = sortNumerical(lines)
-	case flDurationSort:
+	case logfmt.TypeDuration:
 		sl = sortByDuration(lines)
-	case flTimeSort:
+	case logfmt.TypeTime:
 		sl = sortByTime(lines)
 	default:
 		sl = sortAlphabetical(lines)
@@ -164,6 +187,7 @@ var (
 	flNumericSort  bool
 	flDurationSort bool
 	flTimeSort     bool
+	flAutoSort     bool
 
 	rootCmd = &cobra.Command{
 		Use:   "lsort [field]",
@@ -218,6 +242,20 @@ Time sort:
     time=2020-08-12T23:00:11Z baz=qux
     time=2020-08-12T23:01:04Z he=lo
 
+Automatic sort:
+
+With -a the type of sort is inferred from the values of the field: if they are all numbers the sort is numeric,
+if they are all durations it's by duration, if they are all RFC3339 times it's by time, otherwise it's alphabetical.
+
+    $ cat foobar.txt
+	id=200 bar=baz
+	id=3.5 baz=qux
+	id=10 foo=bar
+	$ cat foobar.txt | lsort -a id
+	id=3.5 baz=qux
+	id=10 foo=bar
+	id=200 bar=baz
+
 Note: sorting is done in memory for now so be careful with your input data.`,
 		Args: cobra.ExactArgs(1),
 		RunE: runMain,
@@ -231,7 +269,8 @@ func init() {
 	fs.BoolVarP(&flNumericSort, "numeric-sort", "n", false, "Use a numeric sort instead of a alphabetical sort")
 	fs.BoolVarP(&flDurationSort, "duration-sort", "d", false, "Use a duration sort instead of a alphabetical sort")
 	fs.BoolVarP(&flTimeSort, "time-sort", "t", false, "Use a time sort instead of a alphabetical sort")
-	fs.Var(&flags.MaxLineSize, "max-line-size", "Max size in bytes of a line")
+	fs.BoolVarP(&flAutoSort, "auto-sort", "a", false, "Infer the type of sort from the values of the field")
+	flags.AddRecordFlags(fs)
 	fs.StringVar(&flags.CPUProfile, "cpu-profile", "", "Writes a CPU profile at `cpu-profile` after execution")
 	fs.StringVar(&flags.MemProfile, "mem-profile", "", "Writes a memory profile at `mem-profile` after execution")
 }
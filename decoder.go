@@ -0,0 +1,280 @@
+package logfmt
+
+import (
+	"bufio"
+	"io"
+)
+
+// DefaultMaxLineSize is the maximum size of a line a Decoder accepts unless changed with Decoder.Buffer.
+const DefaultMaxLineSize = 1024 * 1024
+
+// Decoder reads logfmt records from an input stream.
+//
+// Each non-empty line of the input is a record. Empty lines are skipped but still counted in line numbers,
+// except the ones between the continuation lines of a record which are kept in the record.
+//
+// The typical usage is:
+//
+//	dec := logfmt.NewDecoder(r)
+//	for dec.Next() {
+//		pairs := dec.Pairs()
+//		...
+//	}
+//	if err := dec.Err(); err != nil {
+//		...
+//	}
+//
+// A record is only parsed when Pairs is called, so callers only interested in the raw line pay nothing for parsing.
+//
+// If Continuation is set, the lines for which it returns true are joined to the previous record instead of being
+// records of their own, so a stack trace spanning many lines stays with the record logging the error.
+// The maximum size of a line applies to the whole record.
+//
+// If Parser.Strict is set, records are parsed by Next instead and a malformed record makes Next return false
+// with Err returning a *SyntaxError. Bytes and Text still return the malformed record so it can be reported or
+// quarantined, and calling Next again skips it and resumes decoding.
+type Decoder struct {
+	// Parser is used to parse each record. Its options must be set before the first call to Next.
+	Parser PairParser
+	// Prefix, if set, parses the header preceding the logfmt data of each record, like a syslog header.
+	// The pairs of the header come first in Pairs. Records without the header are parsed as plain logfmt.
+	Prefix PrefixParser
+	// Continuation, if set, decides which lines continue the previous record.
+	// The first line of a record is parsed as logfmt; the continuation lines, joined with '\n', are the value of
+	// an additional pair whose key is ContinuationKey, or DefaultContinuationKey if empty.
+	Continuation    ContinuationFunc
+	ContinuationKey string
+
+	scanner *bufio.Scanner
+	maxSize int
+
+	line         []byte
+	firstLineLen int
+	pairs        Pairs
+	parsed       bool
+
+	lineNumber  int
+	offset      int64
+	scanned     int
+	tokenOffset int64
+	consumed    int64
+
+	record        []byte
+	pending       bool
+	pendingLine   []byte
+	pendingNumber int
+	pendingOffset int64
+
+	err error
+}
+
+// NewDecoder returns a new decoder reading from r.
+func NewDecoder(r io.Reader) *Decoder {
+	d := &Decoder{
+		scanner: bufio.NewScanner(r),
+		maxSize: DefaultMaxLineSize,
+		pairs:   make(Pairs, 0, 32),
+	}
+	d.scanner.Split(d.scanLines)
+	d.scanner.Buffer(make([]byte, 4096), DefaultMaxLineSize)
+
+	return d
+}
+
+// Buffer sets the initial buffer to use when reading and the maximum size of a line.
+// It works like bufio.Scanner.Buffer and must be called before the first call to Next.
+func (d *Decoder) Buffer(buf []byte, max int) {
+	d.scanner.Buffer(buf, max)
+	d.maxSize = max
+}
+
+// Next advances the decoder to the next record.
+// It returns false when there are no more records, either because the end of the input was reached or because of an error.
+// After Next returns false, Err returns the error if any.
+func (d *Decoder) Next() bool {
+	if _, ok := d.err.(*SyntaxError); ok {
+		d.err = nil
+	}
+	if d.err != nil {
+		return false
+	}
+
+	line, number, offset, ok := d.scan(false)
+	if !ok {
+		d.line = nil
+		d.err = d.scanner.Err()
+		return false
+	}
+
+	d.line = line
+	d.firstLineLen = len(line)
+	d.lineNumber = number
+	d.offset = offset
+	d.parsed = false
+
+	if d.Continuation != nil {
+		if err := d.assemble(); err != nil {
+			d.line = nil
+			d.err = err
+			return false
+		}
+	}
+
+	if d.Parser.Strict {
+		if err := d.parse(); err != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// scan returns the next line with its number and offset. Empty lines are skipped unless keepEmpty is true.
+func (d *Decoder) scan(keepEmpty bool) ([]byte, int, int64, bool) {
+	if d.pending {
+		d.pending = false
+		return d.pendingLine, d.pendingNumber, d.pendingOffset, true
+	}
+
+	for d.scanner.Scan() {
+		d.scanned++
+
+		line := d.scanner.Bytes()
+		if len(line) == 0 && !keepEmpty {
+			continue
+		}
+
+		return line, d.scanned, d.tokenOffset, true
+	}
+
+	return nil, 0, 0, false
+}
+
+// assemble joins the continuation lines following the current line into the current record.
+// The first line which is not a continuation is kept for the next call to Next.
+//
+// Empty lines are kept if a continuation line follows them. It returns bufio.ErrTooLong if the record is bigger than
+// the maximum size of a line.
+func (d *Decoder) assemble() error {
+	d.record = append(d.record[:0], d.line...)
+
+	empty := 0
+	for {
+		line, number, offset, ok := d.scan(true)
+		if !ok {
+			break
+		}
+
+		if len(line) == 0 {
+			empty++
+			continue
+		}
+
+		if !d.Continuation(line) {
+			d.pending = true
+			d.pendingLine = append(d.pendingLine[:0], line...)
+			d.pendingNumber = number
+			d.pendingOffset = offset
+			break
+		}
+
+		if len(d.record)+empty+1+len(line) > d.maxSize {
+			return bufio.ErrTooLong
+		}
+
+		for ; empty > 0; empty-- {
+			d.record = append(d.record, '\n')
+		}
+		d.record = append(d.record, '\n')
+		d.record = append(d.record, line...)
+	}
+
+	d.line = d.record
+
+	return nil
+}
+
+// Pairs returns the key-value pairs of the current record.
+//
+// The returned slice is reused by the decoder: it is only valid until the next call to Next.
+func (d *Decoder) Pairs() Pairs {
+	if !d.parsed {
+		d.parse()
+	}
+	return d.pairs
+}
+
+func (d *Decoder) parse() error {
+	line := string(d.line[:d.firstLineLen])
+	d.pairs = d.pairs[:0]
+
+	headerLen := 0
+	if d.Prefix != nil {
+		if pairs, rest, ok := d.Prefix.ParsePrefix(line, d.pairs); ok {
+			d.pairs = pairs
+			headerLen = len(line) - len(rest)
+			line = rest
+		}
+	}
+
+	d.pairs = d.Parser.splitAppend(line, d.pairs)
+	d.parsed = true
+
+	if len(d.line) > d.firstLineLen {
+		key := d.ContinuationKey
+		if key == "" {
+			key = DefaultContinuationKey
+		}
+		d.pairs = append(d.pairs, Pair{Key: key, Value: string(d.line[d.firstLineLen+1:])})
+	}
+
+	err := d.Parser.err
+	if err != nil {
+		if serr, ok := err.(*SyntaxError); ok {
+			serr.Line = d.lineNumber
+			serr.Offset += headerLen
+		}
+		d.err = err
+	}
+
+	return err
+}
+
+// Bytes returns the raw line of the current record, without the line terminator.
+// The continuation lines of a multi-line record are included, separated by '\n'.
+//
+// The returned slice is reused by the decoder: it is only valid until the next call to Next.
+func (d *Decoder) Bytes() []byte {
+	return d.line
+}
+
+// Text returns the raw line of the current record as a newly allocated string, without the line terminator.
+func (d *Decoder) Text() string {
+	return string(d.line)
+}
+
+// Line returns the line number of the current record, starting at 1.
+// For a multi-line record it is the number of its first line.
+func (d *Decoder) Line() int {
+	return d.lineNumber
+}
+
+// Offset returns the byte offset of the start of the current record in the input.
+func (d *Decoder) Offset() int64 {
+	return d.offset
+}
+
+// Err returns the first non-EOF error encountered by the decoder.
+func (d *Decoder) Err() error {
+	return d.err
+}
+
+// scanLines wraps bufio.ScanLines to keep track of the byte offset of each line.
+func (d *Decoder) scanLines(data []byte, atEOF bool) (int, []byte, error) {
+	advance, token, err := bufio.ScanLines(data, atEOF)
+	if token != nil {
+		d.tokenOffset = d.consumed
+		d.consumed += int64(advance)
+	}
+	return advance, token, err
+}
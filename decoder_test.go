@@ -0,0 +1,196 @@
+package logfmt
+
+import (
+	"bufio"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecoder(t *testing.T) {
+	const data = "a=b c=d\n\nname=Vincent\r\nage=123"
+
+	type record struct {
+		line   int
+		offset int64
+		text   string
+		pairs  Pairs
+	}
+
+	exp := []record{
+		{1, 0, "a=b c=d", Pairs{{Key: "a", Value: "b"}, {Key: "c", Value: "d"}}},
+		{3, 9, "name=Vincent", Pairs{{Key: "name", Value: "Vincent"}}},
+		{4, 23, "age=123", Pairs{{Key: "age", Value: "123"}}},
+	}
+
+	var res []record
+
+	dec := NewDecoder(strings.NewReader(data))
+	for dec.Next() {
+		res = append(res, record{
+			line:   dec.Line(),
+			offset: dec.Offset(),
+			text:   dec.Text(),
+			pairs:  append(Pairs(nil), dec.Pairs()...),
+		})
+	}
+	require.NoError(t, dec.Err())
+	require.Equal(t, exp, res)
+}
+
+func TestDecoderOffsetMatchesInput(t *testing.T) {
+	const data = "foo=bar\nbar=baz qux=1\n\n\nlast=line\n"
+
+	dec := NewDecoder(strings.NewReader(data))
+	for dec.Next() {
+		offset := int(dec.Offset())
+		require.True(t, strings.HasPrefix(data[offset:], dec.Text()))
+	}
+	require.NoError(t, dec.Err())
+}
+
+func TestDecoderLineTooLong(t *testing.T) {
+	data := "foo=" + strings.Repeat("a", 100)
+
+	dec := NewDecoder(strings.NewReader(data))
+	dec.Buffer(make([]byte, 16), 32)
+
+	require.False(t, dec.Next())
+	require.Equal(t, bufio.ErrTooLong, dec.Err())
+}
+
+func TestDecoderRecordTooLong(t *testing.T) {
+	data := "foo=bar\n" + strings.Repeat("line\n", 10) + "foo=baz\n"
+
+	dec := NewDecoder(strings.NewReader(data))
+	dec.Buffer(make([]byte, 16), 32)
+	dec.Continuation = RecordStartContinuation(regexp.MustCompile(`^foo=`))
+
+	require.False(t, dec.Next())
+	require.Equal(t, bufio.ErrTooLong, dec.Err())
+}
+
+func BenchmarkDecoder(b *testing.B) {
+	const line = `city=Lyon name=Vincent age=123 latitude=0.2982902490 longitude=95.2023904 str="foo bar baz"` + "\n"
+	data := strings.Repeat(line, 1000)
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		dec := NewDecoder(strings.NewReader(data))
+		for dec.Next() {
+			if len(dec.Pairs()) <= 0 {
+				b.Fatal("should have at least one pair")
+			}
+		}
+	}
+}
+
+func TestDecoderStrict(t *testing.T) {
+	const data = "a=b\nc=\"d\n=nope\ne=f\n"
+
+	dec := NewDecoder(strings.NewReader(data))
+	dec.Parser.Strict = true
+
+	var (
+		pairs  Pairs
+		errors []string
+	)
+	for {
+		for dec.Next() {
+			pairs = append(pairs, dec.Pairs()...)
+		}
+
+		serr, ok := dec.Err().(*SyntaxError)
+		if !ok {
+			break
+		}
+		require.Equal(t, dec.Line(), serr.Line)
+		errors = append(errors, dec.Text())
+	}
+
+	require.NoError(t, dec.Err())
+	require.Equal(t, Pairs{{Key: "a", Value: "b"}, {Key: "e", Value: "f"}}, pairs)
+	require.Equal(t, []string{`c="d`, "=nope"}, errors)
+}
+
+func TestDecoderMultiline(t *testing.T) {
+	const data = "time=1 msg=ok\n" +
+		"time=2 msg=\"panic: boom\"\n" +
+		"goroutine 1 [running]:\n" +
+		"\n" +
+		"main.main()\n" +
+		"\t/src/main.go:12\n" +
+		"time=3 msg=done\n" +
+		"\tat Foo.bar(Foo.java:1)"
+
+	type record struct {
+		line   int
+		offset int64
+		text   string
+		pairs  Pairs
+	}
+
+	exp := []record{
+		{1, 0, "time=1 msg=ok", Pairs{{Key: "time", Value: "1"}, {Key: "msg", Value: "ok"}}},
+		{
+			2, 14,
+			"time=2 msg=\"panic: boom\"\ngoroutine 1 [running]:\n\nmain.main()\n\t/src/main.go:12",
+			Pairs{
+				{Key: "time", Value: "2"},
+				{Key: "msg", Value: "panic: boom"},
+				{Key: "trace", Value: "goroutine 1 [running]:\n\nmain.main()\n\t/src/main.go:12"},
+			},
+		},
+		{
+			7, 92,
+			"time=3 msg=done\n\tat Foo.bar(Foo.java:1)",
+			Pairs{
+				{Key: "time", Value: "3"},
+				{Key: "msg", Value: "done"},
+				{Key: "trace", Value: "\tat Foo.bar(Foo.java:1)"},
+			},
+		},
+	}
+
+	var res []record
+
+	dec := NewDecoder(strings.NewReader(data))
+	dec.Continuation = RecordStartContinuation(regexp.MustCompile(`^time=`))
+	dec.ContinuationKey = "trace"
+	for dec.Next() {
+		require.True(t, strings.HasPrefix(data[dec.Offset():], strings.SplitN(dec.Text(), "\n", 2)[0]))
+
+		res = append(res, record{
+			line:   dec.Line(),
+			offset: dec.Offset(),
+			text:   dec.Text(),
+			pairs:  append(Pairs(nil), dec.Pairs()...),
+		})
+	}
+	require.NoError(t, dec.Err())
+	require.Equal(t, exp, res)
+}
+
+func TestDecoderMultilineIndent(t *testing.T) {
+	const data = "  leading=1\nerr=\"java.lang.NullPointerException\"\n\tat Foo.bar(Foo.java:1)\n\n    at Foo.main(Foo.java:2)\n\n\nnext=1"
+
+	var res []Pairs
+
+	dec := NewDecoder(strings.NewReader(data))
+	dec.Continuation = IndentedContinuation
+	for dec.Next() {
+		res = append(res, append(Pairs(nil), dec.Pairs()...))
+	}
+	require.NoError(t, dec.Err())
+	require.Equal(t, []Pairs{
+		{{Key: "leading", Value: "1"}},
+		{
+			{Key: "err", Value: "java.lang.NullPointerException"},
+			{Key: "stack", Value: "\tat Foo.bar(Foo.java:1)\n\n    at Foo.main(Foo.java:2)"},
+		},
+		{{Key: "next", Value: "1"}},
+	}, res)
+}
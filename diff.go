@@ -0,0 +1,223 @@
+package logfmt
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// ChangeKind is the kind of difference between two records found by Diff.
+type ChangeKind int
+
+const (
+	// PairAdded is a pair present only in the second record.
+	PairAdded ChangeKind = iota
+	// PairRemoved is a pair present only in the first record.
+	PairRemoved
+	// PairChanged is a pair present in both records with a different value.
+	PairChanged
+	// PairMoved is a pair present in both records with the same value but not in the same order
+	// relative to the other pairs.
+	PairMoved
+)
+
+var changeKindNames = []string{
+	PairAdded:   "added",
+	PairRemoved: "removed",
+	PairChanged: "changed",
+	PairMoved:   "moved",
+}
+
+func (k ChangeKind) String() string {
+	if k < 0 || int(k) >= len(changeKindNames) {
+		return fmt.Sprintf("ChangeKind(%d)", int(k))
+	}
+	return changeKindNames[k]
+}
+
+// Change is a difference between two records.
+type Change struct {
+	Kind ChangeKind
+	Key  string
+
+	// Old is the pair in the first record and OldIndex its index. They are unset for PairAdded, OldIndex being -1.
+	Old      Pair
+	OldIndex int
+	// New is the pair in the second record and NewIndex its index. They are unset for PairRemoved, NewIndex being -1.
+	New      Pair
+	NewIndex int
+}
+
+// DiffOptions are options for Diff. A zero DiffOptions consists entirely of default values.
+type DiffOptions struct {
+	// IgnoreKeys are keys whose pairs are not compared.
+	IgnoreKeys []string
+	// IgnoreOrder disables the detection of moved pairs.
+	IgnoreOrder bool
+}
+
+// Diff returns the changes between the records a and b, or nil if they are equal.
+//
+// Pairs are matched by key. If a key appears multiple times, its first occurrence in a is matched with its first
+// occurrence in b, the second with the second and so on. Two pairs are equal if their values are equal and they are
+// both bare or both not bare; the Raw text is ignored.
+//
+// A pair whose value changed is reported as PairChanged even if it moved too.
+// The changes are in the order of a, followed by the pairs added in b in the order of b.
+func Diff(a, b Pairs, opts *DiffOptions) Changes {
+	var o DiffOptions
+	if opts != nil {
+		o = *opts
+	}
+
+	// Match the occurrences of each key in b with the ones in a
+	var (
+		indexes     = make(map[string][]int)
+		matched     = make([]int, len(a))
+		matchedInB  = make([]bool, len(b))
+		occurrences = make(map[string]int)
+	)
+	for i, pair := range b {
+		if !containsKey(o.IgnoreKeys, pair.Key) {
+			indexes[pair.Key] = append(indexes[pair.Key], i)
+		}
+	}
+	for i, pair := range a {
+		matched[i] = -1
+		if containsKey(o.IgnoreKeys, pair.Key) {
+			continue
+		}
+
+		n := occurrences[pair.Key]
+		occurrences[pair.Key]++
+		if idx := indexes[pair.Key]; n < len(idx) {
+			matched[i] = idx[n]
+			matchedInB[idx[n]] = true
+		}
+	}
+
+	var moved []bool
+	if !o.IgnoreOrder {
+		moved = movedPairs(matched)
+	}
+
+	//
+
+	var res Changes
+
+	for i, pair := range a {
+		j := matched[i]
+		switch {
+		case containsKey(o.IgnoreKeys, pair.Key):
+		case j == -1:
+			res = append(res, Change{Kind: PairRemoved, Key: pair.Key, Old: pair, OldIndex: i, NewIndex: -1})
+		case !pairsEqual(pair, b[j]):
+			res = append(res, Change{Kind: PairChanged, Key: pair.Key, Old: pair, OldIndex: i, New: b[j], NewIndex: j})
+		case moved != nil && moved[i]:
+			res = append(res, Change{Kind: PairMoved, Key: pair.Key, Old: pair, OldIndex: i, New: b[j], NewIndex: j})
+		}
+	}
+	for j, pair := range b {
+		if !matchedInB[j] && !containsKey(o.IgnoreKeys, pair.Key) {
+			res = append(res, Change{Kind: PairAdded, Key: pair.Key, OldIndex: -1, New: pair, NewIndex: j})
+		}
+	}
+
+	return res
+}
+
+func pairsEqual(a, b Pair) bool {
+	return a.Key == b.Key && a.Value == b.Value && a.Bare == b.Bare
+}
+
+// movedPairs returns which pairs of a moved, given the index in b of each pair of a or -1 if it has no match.
+//
+// The pairs which didn't move are the longest sequence of matched pairs in the same order in a and b;
+// every other matched pair moved. When there are multiple longest sequences the one ending first in a is kept.
+func movedPairs(matched []int) []bool {
+	// lengths[i] is the length of the longest increasing sequence of indexes ending at i, prev[i] the previous
+	// element of that sequence.
+	var (
+		lengths = make([]int, len(matched))
+		prev    = make([]int, len(matched))
+		last    = -1
+	)
+	for i, j := range matched {
+		prev[i] = -1
+		if j == -1 {
+			continue
+		}
+
+		lengths[i] = 1
+		for k := 0; k < i; k++ {
+			if matched[k] != -1 && matched[k] < j && lengths[k]+1 > lengths[i] {
+				lengths[i] = lengths[k] + 1
+				prev[i] = k
+			}
+		}
+		if last == -1 || lengths[i] > lengths[last] {
+			last = i
+		}
+	}
+
+	res := make([]bool, len(matched))
+	for i, j := range matched {
+		res[i] = j != -1
+	}
+	for i := last; i != -1; i = prev[i] {
+		res[i] = false
+	}
+
+	return res
+}
+
+// Changes is a list of changes returned by Diff.
+type Changes []Change
+
+// AppendFormat appends the changes to b in logfmt, one line per change, and returns the extended buffer.
+//
+// Each line has the kind of change, the key and the values of the pair, like this:
+//
+//	change=added key=user new=bob
+//	change=removed key=cached old
+//	change=changed key=status old=200 new=500
+//	change=moved key=level old_index=1 new_index=3
+//
+// A bare key in a record is written as a bare old or new key.
+func (c Changes) AppendFormat(b []byte) []byte {
+	pairs := make(Pairs, 0, 4)
+
+	for _, change := range c {
+		pairs = append(pairs[:0],
+			Pair{Key: "change", Value: change.Kind.String()},
+			Pair{Key: "key", Value: change.Key},
+		)
+
+		switch change.Kind {
+		case PairAdded:
+			pairs = append(pairs, diffValue("new", change.New))
+		case PairRemoved:
+			pairs = append(pairs, diffValue("old", change.Old))
+		case PairChanged:
+			pairs = append(pairs, diffValue("old", change.Old), diffValue("new", change.New))
+		case PairMoved:
+			pairs = append(pairs,
+				Pair{Key: "old_index", Value: strconv.Itoa(change.OldIndex)},
+				Pair{Key: "new_index", Value: strconv.Itoa(change.NewIndex)},
+			)
+		}
+
+		b = pairs.AppendFormat(b)
+		b = append(b, '\n')
+	}
+
+	return b
+}
+
+// Format formats the changes in logfmt, see AppendFormat.
+func (c Changes) Format() string {
+	return string(c.AppendFormat(nil))
+}
+
+func diffValue(key string, pair Pair) Pair {
+	return Pair{Key: key, Value: pair.Value, Bare: pair.Bare}
+}
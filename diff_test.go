@@ -0,0 +1,64 @@
+package logfmt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	testCases := []struct {
+		a, b string
+		opts *DiffOptions
+		exp  Changes
+	}{
+		{`a=1 b=2`, `a=1 b=2`, nil, nil},
+		{`a=1 b=2 c`, `a=1 b=3 d=4`, nil, Changes{
+			{Kind: PairChanged, Key: "b", Old: Pair{Key: "b", Value: "2"}, OldIndex: 1, New: Pair{Key: "b", Value: "3"}, NewIndex: 1},
+			{Kind: PairRemoved, Key: "c", Old: Pair{Key: "c", Bare: true}, OldIndex: 2, NewIndex: -1},
+			{Kind: PairAdded, Key: "d", OldIndex: -1, New: Pair{Key: "d", Value: "4"}, NewIndex: 2},
+		}},
+		{`a b=`, `a= b`, nil, Changes{
+			{Kind: PairChanged, Key: "a", Old: Pair{Key: "a", Bare: true}, OldIndex: 0, New: Pair{Key: "a"}, NewIndex: 0},
+			{Kind: PairChanged, Key: "b", Old: Pair{Key: "b"}, OldIndex: 1, New: Pair{Key: "b", Bare: true}, NewIndex: 1},
+		}},
+		{`a=1 b=2 c=3`, `b=2 c=3 a=1`, nil, Changes{
+			{Kind: PairMoved, Key: "a", Old: Pair{Key: "a", Value: "1"}, OldIndex: 0, New: Pair{Key: "a", Value: "1"}, NewIndex: 2},
+		}},
+		{`a=1 b=2 c=3`, `b=2 c=3 a=1`, &DiffOptions{IgnoreOrder: true}, nil},
+		{`a=1 b=2 c=3`, `b=2 c=4 a=1`, &DiffOptions{IgnoreOrder: true}, Changes{
+			{Kind: PairChanged, Key: "c", Old: Pair{Key: "c", Value: "3"}, OldIndex: 2, New: Pair{Key: "c", Value: "4"}, NewIndex: 1},
+		}},
+		{`tag=a tag=b`, `tag=a tag=c tag=d`, nil, Changes{
+			{Kind: PairChanged, Key: "tag", Old: Pair{Key: "tag", Value: "b"}, OldIndex: 1, New: Pair{Key: "tag", Value: "c"}, NewIndex: 1},
+			{Kind: PairAdded, Key: "tag", OldIndex: -1, New: Pair{Key: "tag", Value: "d"}, NewIndex: 2},
+		}},
+		{`time=1 id=2 ok`, `time=3 ok id=2 req=4`, &DiffOptions{IgnoreKeys: []string{"time", "req"}}, Changes{
+			{Kind: PairMoved, Key: "ok", Old: Pair{Key: "ok", Bare: true}, OldIndex: 2, New: Pair{Key: "ok", Bare: true}, NewIndex: 1},
+		}},
+	}
+
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			res := Diff(Split(tc.a), Split(tc.b), tc.opts)
+			require.Equal(t, tc.exp, res)
+		})
+	}
+}
+
+func TestChangesFormat(t *testing.T) {
+	changes := Diff(
+		Split(`level=info status=200 cached msg="all good"`),
+		Split(`status=500 msg="not good" level=info user=bob`),
+		nil,
+	)
+
+	exp := `change=moved key=level old_index=0 new_index=2
+change=changed key=status old=200 new=500
+change=removed key=cached old
+change=changed key=msg old="all good" new="not good"
+change=added key=user new=bob
+`
+	require.Equal(t, exp, changes.Format())
+	require.Equal(t, "", Diff(Split("a=1"), Split("a=1"), nil).Format())
+}
@@ -0,0 +1,162 @@
+package logfmt
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// DuplicatePolicy defines what a PairParser does with keys appearing multiple times in a line.
+type DuplicatePolicy int
+
+const (
+	// KeepAllDuplicates keeps every pair, in order. This is the default.
+	KeepAllDuplicates DuplicatePolicy = iota
+	// FirstDuplicateWins keeps only the first pair with a given key.
+	FirstDuplicateWins
+	// LastDuplicateWins keeps only the value of the last pair with a given key.
+	// The pair stays at the position of the first occurrence of the key.
+	LastDuplicateWins
+	// MergeDuplicates merges all pairs with a given key into a single pair at the position of the first occurrence.
+	// Its value is the list of their values as a JSON array of strings, like a=["x,y","z"] for a=x,y a=z.
+	// Bare keys have no value to merge: they are skipped, and a key which is always bare stays a bare key.
+	MergeDuplicates
+)
+
+var duplicatePolicyNames = []string{
+	KeepAllDuplicates:  "all",
+	FirstDuplicateWins: "first",
+	LastDuplicateWins:  "last",
+	MergeDuplicates:    "merge",
+}
+
+// ParseDuplicatePolicy parses the name of a policy: "all", "first", "last" or "merge".
+func ParseDuplicatePolicy(s string) (DuplicatePolicy, error) {
+	for i, name := range duplicatePolicyNames {
+		if name == s {
+			return DuplicatePolicy(i), nil
+		}
+	}
+	return KeepAllDuplicates, fmt.Errorf("logfmt: invalid duplicate policy %q", s)
+}
+
+func (d DuplicatePolicy) String() string {
+	if d < 0 || int(d) >= len(duplicatePolicyNames) {
+		return fmt.Sprintf("DuplicatePolicy(%d)", int(d))
+	}
+	return duplicatePolicyNames[d]
+}
+
+// indexThreshold is the number of pairs above which an index is used to find duplicates.
+const indexThreshold = 32
+
+// applyDuplicatePolicy removes or merges the duplicate keys of pairs according to the policy.
+// The pairs are modified in place.
+func (p *PairParser) applyDuplicatePolicy(pairs Pairs) Pairs {
+	if p.Duplicates == KeepAllDuplicates || len(pairs) < 2 {
+		return pairs
+	}
+
+	useIndex := len(pairs) > indexThreshold
+	if useIndex {
+		if p.seen == nil {
+			p.seen = make(map[string]int, len(pairs))
+		}
+		for k := range p.seen {
+			delete(p.seen, k)
+		}
+	}
+
+	var merged []bool // merged[j] is true if the value of res[j] is a list of merged values
+
+	res := pairs[:0]
+	for _, pair := range pairs {
+		var j int
+		if useIndex {
+			var ok bool
+			if j, ok = p.seen[pair.Key]; !ok {
+				j = -1
+				p.seen[pair.Key] = len(res)
+			}
+		} else {
+			j = res.Index(pair.Key)
+		}
+
+		switch {
+		case j == -1:
+			res = append(res, pair)
+		case p.Duplicates == LastDuplicateWins:
+			res[j] = pair
+		case p.Duplicates == MergeDuplicates:
+			if merged == nil {
+				merged = make([]bool, len(pairs))
+			}
+
+			var list []byte
+			switch {
+			case merged[j] && !res[j].Bare:
+				list = []byte(res[j].Value)
+			case !merged[j] && !res[j].Bare:
+				list = appendMergedValue(nil, res[j].Value)
+			}
+			merged[j] = true
+
+			if !pair.Bare {
+				list = appendMergedValue(list, pair.Value)
+			}
+			if len(list) > 0 {
+				res[j].Value, res[j].Bare = string(list), false
+			}
+		}
+	}
+
+	return res
+}
+
+// appendMergedValue appends value to list, a JSON array of strings, or starts the array if list is empty.
+func appendMergedValue(list []byte, value string) []byte {
+	if len(list) == 0 {
+		list = append(list, '[')
+	} else {
+		list[len(list)-1] = ','
+	}
+	list = appendJSONString(list, value)
+	return append(list, ']')
+}
+
+// appendJSONString appends s to b as a JSON string. Invalid UTF-8 bytes are replaced with utf8.RuneError.
+func appendJSONString(b []byte, s string) []byte {
+	const hex = "0123456789abcdef"
+
+	b = append(b, '"')
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c >= utf8.RuneSelf {
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if r == utf8.RuneError && size == 1 {
+				b = append(b, `\ufffd`...)
+			} else {
+				b = append(b, s[i:i+size]...)
+			}
+			i += size
+			continue
+		}
+
+		switch {
+		case c == '"' || c == '\\':
+			b = append(b, '\\', c)
+		case c == '\n':
+			b = append(b, `\n`...)
+		case c == '\r':
+			b = append(b, `\r`...)
+		case c == '\t':
+			b = append(b, `\t`...)
+		case c < 0x20:
+			b = append(b, `\u00`...)
+			b = append(b, hex[c>>4], hex[c&0xf])
+		default:
+			b = append(b, c)
+		}
+		i++
+	}
+	return append(b, '"')
+}
@@ -0,0 +1,90 @@
+package logfmt
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDuplicatePolicy(t *testing.T) {
+	const line = "status=200 path=/ status=500 retry status=503"
+
+	testCases := []struct {
+		policy DuplicatePolicy
+		exp    string
+	}{
+		{KeepAllDuplicates, "status=200 path=/ status=500 retry status=503"},
+		{FirstDuplicateWins, "status=200 path=/ retry"},
+		{LastDuplicateWins, "status=503 path=/ retry"},
+		{MergeDuplicates, `status="[\"200\",\"500\",\"503\"]" path=/ retry`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.policy.String(), func(t *testing.T) {
+			parser := PairParser{Duplicates: tc.policy}
+			require.Equal(t, tc.exp, parser.Split(line).Format())
+		})
+	}
+}
+
+func TestMergeDuplicates(t *testing.T) {
+	testCases := []struct {
+		input string
+		exp   Pairs
+	}{
+		{`a=x,y a=z`, Pairs{{Key: "a", Value: `["x,y","z"]`}}},
+		{`retry retry=1`, Pairs{{Key: "retry", Value: `["1"]`}}},
+		{`retry=1 retry`, Pairs{{Key: "retry", Value: `["1"]`}}},
+		{`retry retry`, Pairs{{Key: "retry", Bare: true}}},
+		{`a= a="b \"c\"" b=1 a="\t\u00e9"`, Pairs{{Key: "a", Value: `["","b \"c\"","\té"]`}, {Key: "b", Value: "1"}}},
+		{"a=\xff a=\x01", Pairs{{Key: "a", Value: `["\ufffd","\u0001"]`}}},
+	}
+
+	parser := PairParser{Duplicates: MergeDuplicates}
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			pairs := parser.Split(tc.input)
+			require.Equal(t, tc.exp, pairs)
+
+			if !pairs[0].Bare {
+				var values []string
+				require.NoError(t, json.Unmarshal([]byte(pairs[0].Value), &values))
+			}
+		})
+	}
+}
+
+func TestDuplicatePolicyManyPairs(t *testing.T) {
+	var (
+		sb  strings.Builder
+		exp strings.Builder
+	)
+	for i := 0; i < 100; i++ {
+		sb.WriteString("k" + strconv.Itoa(i%50) + "=" + strconv.Itoa(i) + " ")
+		if i < 50 {
+			if i > 0 {
+				exp.WriteString(" ")
+			}
+			exp.WriteString("k" + strconv.Itoa(i) + "=" + strconv.Itoa(i+50))
+		}
+	}
+
+	parser := PairParser{Duplicates: LastDuplicateWins}
+	for i := 0; i < 2; i++ {
+		require.Equal(t, exp.String(), parser.Split(sb.String()).Format())
+	}
+}
+
+func TestParseDuplicatePolicy(t *testing.T) {
+	for _, name := range []string{"all", "first", "last", "merge"} {
+		policy, err := ParseDuplicatePolicy(name)
+		require.NoError(t, err)
+		require.Equal(t, name, policy.String())
+	}
+
+	_, err := ParseDuplicatePolicy("random")
+	require.Error(t, err)
+}
@@ -0,0 +1,176 @@
+package logfmt
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+var (
+	// ErrInvalidKey is returned by the Encoder when a key is empty.
+	ErrInvalidKey = errors.New("logfmt: invalid key")
+	// ErrMissingValue is returned by Encoder.EncodeKeyvals when given an odd number of arguments.
+	ErrMissingValue = errors.New("logfmt: missing value")
+)
+
+// Encoder writes logfmt records to an output stream.
+//
+// Pairs are accumulated in the current record with EncodeKeyval, EncodeKeyvals or EncodePairs;
+// the record is written to the output, followed by a newline, when calling EndRecord.
+//
+// Values are quoted and keys sanitized following the same rules as Pairs.AppendFormat, so every record written
+// can be parsed back with Split.
+type Encoder struct {
+	w   io.Writer
+	buf []byte
+}
+
+// NewEncoder returns a new encoder writing to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{
+		w:   w,
+		buf: make([]byte, 0, 1024),
+	}
+}
+
+// EncodeKeyval adds a key-value pair to the current record.
+//
+// The value is formatted depending on its type:
+//   - nil and nil pointers are formatted as null
+//   - strings and byte slices are used as is
+//   - booleans and numbers are formatted with the strconv package
+//   - time.Time is formatted with time.RFC3339Nano and time.Duration with its String method
+//   - errors, fmt.Stringer and encoding.TextMarshaler are formatted with their Error, String or MarshalText methods
+//   - anything else is formatted with fmt.Sprint
+func (e *Encoder) EncodeKeyval(key string, value interface{}) error {
+	if key == "" {
+		return ErrInvalidKey
+	}
+
+	s, err := formatValue(value)
+	if err != nil {
+		return err
+	}
+
+	e.buf = appendSeparator(e.buf)
+	e.buf = appendKey(e.buf, key)
+	e.buf = append(e.buf, '=')
+	e.buf = appendValue(e.buf, s)
+
+	return nil
+}
+
+// EncodeKeyvals adds alternating keys and values to the current record.
+// Keys must be strings; values are formatted like with EncodeKeyval.
+func (e *Encoder) EncodeKeyvals(keyvals ...interface{}) error {
+	if len(keyvals)%2 != 0 {
+		return ErrMissingValue
+	}
+
+	for i := 0; i < len(keyvals); i += 2 {
+		key, ok := keyvals[i].(string)
+		if !ok {
+			return fmt.Errorf("logfmt: key %v is a %T, not a string", keyvals[i], keyvals[i])
+		}
+		if err := e.EncodeKeyval(key, keyvals[i+1]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// EncodePairs adds the pairs to the current record.
+func (e *Encoder) EncodePairs(pairs Pairs) error {
+	for _, pair := range pairs {
+		if pair.Key == "" {
+			return ErrInvalidKey
+		}
+	}
+	if len(pairs) == 0 {
+		return nil
+	}
+
+	e.buf = appendSeparator(e.buf)
+	e.buf = pairs.AppendFormat(e.buf)
+
+	return nil
+}
+
+// EndRecord writes the current record followed by a newline to the output and starts a new record.
+func (e *Encoder) EndRecord() error {
+	e.buf = append(e.buf, '\n')
+	_, err := e.w.Write(e.buf)
+	e.buf = e.buf[:0]
+
+	return err
+}
+
+// Reset discards the current record.
+func (e *Encoder) Reset() {
+	e.buf = e.buf[:0]
+}
+
+func formatValue(value interface{}) (string, error) {
+	if value == nil {
+		return "null", nil
+	}
+	if rv := reflect.ValueOf(value); rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return "null", nil
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int8:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int16:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int32:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case uint:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint8:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint16:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint32:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case uintptr:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32), nil
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case time.Duration:
+		return v.String(), nil
+	case error:
+		return v.Error(), nil
+	case fmt.Stringer:
+		return v.String(), nil
+	case encoding.TextMarshaler:
+		data, err := v.MarshalText()
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
@@ -0,0 +1,118 @@
+package logfmt
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type stringer struct{}
+
+func (stringer) String() string { return "i am a stringer" }
+
+func TestEncoderKeyval(t *testing.T) {
+	var (
+		nilErr  error
+		nilPtr  *int
+		nilStr  *stringer
+		ip      = net.ParseIP("127.0.0.1")
+		someErr = errors.New("connection refused")
+	)
+
+	testCases := []struct {
+		value interface{}
+		exp   string
+	}{
+		{"foo", `k=foo`},
+		{"foo bar", `k="foo bar"`},
+		{"", `k=""`},
+		{[]byte("data"), `k=data`},
+		{true, `k=true`},
+		{-12, `k=-12`},
+		{int8(8), `k=8`},
+		{uint64(1 << 63), `k=9223372036854775808`},
+		{float32(0.25), `k=0.25`},
+		{1.5e-7, `k=1.5e-07`},
+		{time.Date(2026, 10, 17, 12, 0, 1, 500, time.UTC), `k=2026-10-17T12:00:01.0000005Z`},
+		{1500 * time.Millisecond, `k=1.5s`},
+		{someErr, `k="connection refused"`},
+		{stringer{}, `k="i am a stringer"`},
+		{ip, `k=127.0.0.1`},
+		{nil, `k=null`},
+		{nilErr, `k=null`},
+		{nilPtr, `k=null`},
+		{nilStr, `k=null`},
+		{[]int{1, 2}, `k="[1 2]"`},
+	}
+
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			var buf bytes.Buffer
+
+			enc := NewEncoder(&buf)
+			require.NoError(t, enc.EncodeKeyval("k", tc.value))
+			require.NoError(t, enc.EndRecord())
+			require.Equal(t, tc.exp+"\n", buf.String())
+		})
+	}
+}
+
+func TestEncoderRecords(t *testing.T) {
+	var buf bytes.Buffer
+
+	enc := NewEncoder(&buf)
+
+	require.NoError(t, enc.EncodeKeyvals("level", "info", "msg", "hello\nworld", "status", 200))
+	require.NoError(t, enc.EncodePairs(Pairs{{Key: "retry", Bare: true}, {Key: "tags", Value: ""}}))
+	require.NoError(t, enc.EndRecord())
+
+	require.NoError(t, enc.EncodeKeyval("bad key", "v"))
+	enc.Reset()
+	require.NoError(t, enc.EncodeKeyval("a", "b"))
+	require.NoError(t, enc.EndRecord())
+
+	exp := "level=info msg=\"hello\\nworld\" status=200 retry tags=\"\"\na=b\n"
+	require.Equal(t, exp, buf.String())
+
+	var records []Pairs
+	dec := NewDecoder(strings.NewReader(buf.String()))
+	for dec.Next() {
+		records = append(records, append(Pairs(nil), dec.Pairs()...))
+	}
+	require.NoError(t, dec.Err())
+	require.Equal(t, []Pairs{
+		{
+			{Key: "level", Value: "info"},
+			{Key: "msg", Value: "hello\nworld"},
+			{Key: "status", Value: "200"},
+			{Key: "retry", Bare: true},
+			{Key: "tags", Value: ""},
+		},
+		{
+			{Key: "a", Value: "b"},
+		},
+	}, records)
+}
+
+func TestEncoderErrors(t *testing.T) {
+	enc := NewEncoder(new(bytes.Buffer))
+
+	require.Equal(t, ErrInvalidKey, enc.EncodeKeyval("", "v"))
+	require.Equal(t, ErrMissingValue, enc.EncodeKeyvals("a", "b", "c"))
+	require.Error(t, enc.EncodeKeyvals(1, "b"))
+	require.Equal(t, ErrInvalidKey, enc.EncodePairs(Pairs{{Key: "", Value: "v"}}))
+}
+
+func TestEncoderSanitizesKeys(t *testing.T) {
+	var buf bytes.Buffer
+
+	enc := NewEncoder(&buf)
+	require.NoError(t, enc.EncodeKeyval("my key=1", "v"))
+	require.NoError(t, enc.EndRecord())
+	require.Equal(t, "my_key_1=v\n", buf.String())
+}
@@ -0,0 +1,48 @@
+package logfmt
+
+import (
+	"strconv"
+)
+
+const (
+	reasonEmptyKey           = "empty key"
+	reasonUnterminatedQuote  = "unterminated quoted value"
+	reasonInvalidQuotedValue = "invalid escape sequence in quoted value"
+	reasonQuoteInValue       = "double quote in unquoted value"
+	reasonMissingSeparator   = "missing space after quoted value"
+)
+
+// SyntaxError describes a malformed logfmt line.
+// It is only returned by a PairParser in strict mode.
+type SyntaxError struct {
+	// Line is the line number of the malformed record in the input, starting at 1.
+	// It is only set when the error comes from a Decoder.
+	Line int
+	// Offset is the byte offset in the line where the malformed pair starts.
+	Offset int
+	// Key is the key of the malformed pair, if it could be read.
+	Key string
+	// Reason describes what is wrong with the pair.
+	Reason string
+}
+
+func (e *SyntaxError) Error() string {
+	b := make([]byte, 0, 64)
+	b = append(b, "logfmt: syntax error at "...)
+	if e.Line > 0 {
+		b = append(b, "line "...)
+		b = strconv.AppendInt(b, int64(e.Line), 10)
+		b = append(b, ", "...)
+	}
+	b = append(b, "offset "...)
+	b = strconv.AppendInt(b, int64(e.Offset), 10)
+	if e.Key != "" {
+		b = append(b, " (key "...)
+		b = strconv.AppendQuote(b, e.Key)
+		b = append(b, ')')
+	}
+	b = append(b, ": "...)
+	b = append(b, e.Reason...)
+
+	return string(b)
+}
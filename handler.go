@@ -0,0 +1,206 @@
+package logfmt
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// HandlerOptions are options for a Handler. A zero HandlerOptions consists entirely of default values.
+type HandlerOptions struct {
+	// AddSource causes the handler to add a "source" pair with the file and line of the log statement.
+	AddSource bool
+
+	// Level reports the minimum record level that will be logged. It defaults to slog.LevelInfo.
+	Level slog.Leveler
+
+	// TimeFormat is the layout used to format the time of a record. It defaults to time.RFC3339Nano.
+	TimeFormat string
+
+	// ReplaceAttr is called to rewrite each non-group attribute before it is logged, see slog.HandlerOptions.
+	// The built-in attributes with keys slog.TimeKey, slog.LevelKey, slog.SourceKey and slog.MessageKey
+	// are passed with a nil groups slice.
+	ReplaceAttr func(groups []string, a slog.Attr) slog.Attr
+}
+
+// Handler is a slog.Handler which writes records as logfmt lines to an io.Writer.
+//
+// Attributes in groups are flattened: their key is prefixed with the group names separated by dots,
+// so slog.Group("req", "method", "GET") is written as req.method=GET.
+// Keys and values are formatted like Pairs.AppendFormat does, therefore a line written by the handler
+// can be parsed back with Split.
+type Handler struct {
+	opts HandlerOptions
+
+	preformatted []byte
+	prefix       string
+	groups       []string
+
+	mu *sync.Mutex
+	w  io.Writer
+}
+
+// NewHandler creates a Handler writing to w, using the given options.
+// If opts is nil the default options are used.
+func NewHandler(w io.Writer, opts *HandlerOptions) *Handler {
+	h := &Handler{
+		mu: new(sync.Mutex),
+		w:  w,
+	}
+	if opts != nil {
+		h.opts = *opts
+	}
+	if h.opts.TimeFormat == "" {
+		h.opts.TimeFormat = time.RFC3339Nano
+	}
+
+	return h
+}
+
+// Enabled reports whether the handler handles records at the given level.
+func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	minLevel := slog.LevelInfo
+	if h.opts.Level != nil {
+		minLevel = h.opts.Level.Level()
+	}
+	return level >= minLevel
+}
+
+// WithAttrs returns a new Handler whose output starts with the given attributes.
+// The attributes are formatted once here instead of for every record.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+
+	h2 := h.clone()
+	for _, a := range attrs {
+		h2.preformatted = h2.appendAttr(h2.preformatted, h2.prefix, h2.groups, a)
+	}
+
+	return h2
+}
+
+// WithGroup returns a new Handler which prefixes the keys of the following attributes with the group name.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	h2 := h.clone()
+	h2.prefix += name + "."
+	h2.groups = append(h2.groups, name)
+
+	return h2
+}
+
+// Handle formats the record as a single logfmt line and writes it.
+func (h *Handler) Handle(_ context.Context, r slog.Record) error {
+	buf := make([]byte, 0, 1024)
+
+	if !r.Time.IsZero() {
+		buf = h.appendBuiltin(buf, slog.Time(slog.TimeKey, r.Time))
+	}
+	buf = h.appendBuiltin(buf, slog.Any(slog.LevelKey, r.Level))
+	if h.opts.AddSource && r.PC != 0 {
+		frames := runtime.CallersFrames([]uintptr{r.PC})
+		frame, _ := frames.Next()
+		buf = h.appendBuiltin(buf, slog.String(slog.SourceKey, frame.File+":"+strconv.Itoa(frame.Line)))
+	}
+	buf = h.appendBuiltin(buf, slog.String(slog.MessageKey, r.Message))
+
+	if len(h.preformatted) > 0 {
+		buf = appendSeparator(buf)
+		buf = append(buf, h.preformatted...)
+	}
+
+	r.Attrs(func(a slog.Attr) bool {
+		buf = h.appendAttr(buf, h.prefix, h.groups, a)
+		return true
+	})
+
+	buf = append(buf, '\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	_, err := h.w.Write(buf)
+
+	return err
+}
+
+func (h *Handler) clone() *Handler {
+	return &Handler{
+		opts:         h.opts,
+		preformatted: append([]byte(nil), h.preformatted...),
+		prefix:       h.prefix,
+		groups:       append([]string(nil), h.groups...),
+		mu:           h.mu,
+		w:            h.w,
+	}
+}
+
+func (h *Handler) appendBuiltin(buf []byte, a slog.Attr) []byte {
+	if h.opts.ReplaceAttr != nil {
+		a = h.opts.ReplaceAttr(nil, a)
+	}
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return buf
+	}
+
+	return h.appendPair(buf, a.Key, a.Value)
+}
+
+func (h *Handler) appendAttr(buf []byte, prefix string, groups []string, a slog.Attr) []byte {
+	a.Value = a.Value.Resolve()
+	if h.opts.ReplaceAttr != nil && a.Value.Kind() != slog.KindGroup {
+		a = h.opts.ReplaceAttr(groups, a)
+		a.Value = a.Value.Resolve()
+	}
+	if a.Equal(slog.Attr{}) {
+		return buf
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+			groups = append(groups[:len(groups):len(groups)], a.Key)
+		}
+		for _, ga := range a.Value.Group() {
+			buf = h.appendAttr(buf, prefix, groups, ga)
+		}
+		return buf
+	}
+
+	return h.appendPair(buf, prefix+a.Key, a.Value)
+}
+
+func (h *Handler) appendPair(buf []byte, key string, value slog.Value) []byte {
+	buf = appendSeparator(buf)
+	buf = appendKey(buf, key)
+	buf = append(buf, '=')
+	return appendValue(buf, h.formatValue(value))
+}
+
+func (h *Handler) formatValue(v slog.Value) string {
+	switch v.Kind() {
+	case slog.KindString:
+		return v.String()
+	case slog.KindTime:
+		return v.Time().Format(h.opts.TimeFormat)
+	case slog.KindAny:
+		s, err := formatValue(v.Any())
+		if err != nil {
+			return "!ERROR:" + err.Error()
+		}
+		return s
+	default:
+		// Int64, Uint64, Float64, Bool and Duration
+		return v.String()
+	}
+}
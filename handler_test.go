@@ -0,0 +1,118 @@
+package logfmt
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"testing/slogtest"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlerSlogtest(t *testing.T) {
+	var buf bytes.Buffer
+
+	h := NewHandler(&buf, nil)
+
+	results := func() []map[string]interface{} {
+		var res []map[string]interface{}
+
+		dec := NewDecoder(strings.NewReader(buf.String()))
+		for dec.Next() {
+			m := make(map[string]interface{})
+			for _, pair := range dec.Pairs() {
+				// Rebuild the groups from the dotted keys
+				cur := m
+				keys := strings.Split(pair.Key, ".")
+				for _, k := range keys[:len(keys)-1] {
+					sub, ok := cur[k].(map[string]interface{})
+					if !ok {
+						sub = make(map[string]interface{})
+						cur[k] = sub
+					}
+					cur = sub
+				}
+				cur[keys[len(keys)-1]] = pair.Value
+			}
+			res = append(res, m)
+		}
+		require.NoError(t, dec.Err())
+
+		return res
+	}
+
+	require.NoError(t, slogtest.TestHandler(h, results))
+}
+
+func TestHandlerOutput(t *testing.T) {
+	var buf bytes.Buffer
+
+	h := NewHandler(&buf, &HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			if a.Key == "password" {
+				return slog.String(a.Key, "***")
+			}
+			return a
+		},
+	})
+
+	logger := slog.New(h).With("service", "api").WithGroup("http").With("method", "GET")
+	logger.Debug("request done",
+		"path", "/foo bar",
+		"status", 200,
+		"elapsed", 1500*time.Millisecond,
+		"err", errors.New("boom"),
+		"password", "hunter2",
+		slog.Group("user", "id", 10, "name", ""),
+		slog.Group("empty"),
+	)
+
+	exp := `level=DEBUG msg="request done" service=api http.method=GET http.path="/foo bar" http.status=200 http.elapsed=1.5s http.err=boom http.password=*** http.user.id=10 http.user.name=""` + "\n"
+	require.Equal(t, exp, buf.String())
+
+	require.Equal(t, Pairs{
+		{Key: "level", Value: "DEBUG"},
+		{Key: "msg", Value: "request done"},
+		{Key: "service", Value: "api"},
+		{Key: "http.method", Value: "GET"},
+		{Key: "http.path", Value: "/foo bar"},
+		{Key: "http.status", Value: "200"},
+		{Key: "http.elapsed", Value: "1.5s"},
+		{Key: "http.err", Value: "boom"},
+		{Key: "http.password", Value: "***"},
+		{Key: "http.user.id", Value: "10"},
+		{Key: "http.user.name", Value: ""},
+	}, Split(strings.TrimSuffix(buf.String(), "\n")))
+}
+
+func TestHandlerOptions(t *testing.T) {
+	var buf bytes.Buffer
+
+	h := NewHandler(&buf, &HandlerOptions{
+		AddSource:  true,
+		Level:      slog.LevelWarn,
+		TimeFormat: time.Kitchen,
+	})
+	logger := slog.New(h)
+
+	logger.Info("ignored")
+	require.Empty(t, buf.String())
+
+	logger.Warn("multi\nline")
+
+	pairs := Split(strings.TrimSuffix(buf.String(), "\n"))
+	require.Equal(t, []string{"time", "level", "source", "msg"}, []string{pairs[0].Key, pairs[1].Key, pairs[2].Key, pairs[3].Key})
+
+	_, err := time.Parse(time.Kitchen, pairs.Get("time"))
+	require.NoError(t, err)
+	require.Equal(t, "WARN", pairs.Get("level"))
+	require.Contains(t, pairs.Get("source"), "handler_test.go:")
+	require.Equal(t, "multi\nline", pairs.Get("msg"))
+}
@@ -0,0 +1,34 @@
+package internal
+
+import (
+	"io"
+
+	"github.com/vrischmann/logfmt"
+	"github.com/vrischmann/logfmt/internal/flags"
+)
+
+// NewDecoder returns a logfmt decoder reading from `r` configured with the shared command line flags.
+func NewDecoder(r io.Reader) *logfmt.Decoder {
+	dec := logfmt.NewDecoder(r)
+	dec.Buffer(make([]byte, int(flags.MaxLineSize)/2), int(flags.MaxLineSize))
+	dec.Parser.Duplicates = logfmt.DuplicatePolicy(flags.Duplicates)
+	dec.Prefix = flags.Prefix.Parser
+
+	var continuations []logfmt.ContinuationFunc
+	if flags.MultilineIndent {
+		continuations = append(continuations, logfmt.IndentedContinuation)
+	}
+	if flags.RecordStart.Regexp != nil {
+		continuations = append(continuations, logfmt.RecordStartContinuation(flags.RecordStart.Regexp))
+	}
+	switch len(continuations) {
+	case 0:
+	case 1:
+		dec.Continuation = continuations[0]
+	default:
+		dec.Continuation = logfmt.AnyContinuation(continuations...)
+	}
+	dec.ContinuationKey = flags.ContinuationKey
+
+	return dec
+}
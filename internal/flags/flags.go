@@ -2,16 +2,47 @@ package flags
 
 import (
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"github.com/vrischmann/logfmt"
 )
 
 var (
 	CPUProfile  string
 	MemProfile  string
 	MaxLineSize Size
+	Duplicates  DuplicatePolicy
+	Prefix      PrefixParser
+
+	MultilineIndent bool
+	RecordStart     Regexp
+	ContinuationKey string
+
+	Since       Time
+	Until       Time
+	TimeKey     string
+	TimeOrdered bool
 )
 
+// AddRecordFlags registers the flags describing how to read the records of the inputs, shared by every command.
+func AddRecordFlags(fs *pflag.FlagSet) {
+	fs.Var(&MaxLineSize, "max-line-size", "Max size in bytes of a line")
+	fs.Var(&Duplicates, "duplicates", "How to handle keys appearing multiple times in a line: all, first, last or merge")
+	fs.Var(&Prefix, "prefix", "Parse a header preceding the logfmt data of each line into pairs: syslog, cri, docker, auto or none")
+	fs.BoolVar(&MultilineIndent, "multiline-indent", false, "Join lines starting with a space or a tab to the previous record, like stack traces")
+	fs.Var(&RecordStart, "record-start", "Join lines not matching this regexp to the previous record, for example ^time=")
+	fs.StringVar(&ContinuationKey, "continuation-key", logfmt.DefaultContinuationKey, "Key of the pair holding the lines joined to a record")
+	fs.Var(&Since, "since", "Only read the records whose time is at or after this time, like 2026-10-17T10:00:00Z or -15m")
+	fs.Var(&Until, "until", "Only read the records whose time is at or before this time, like 2026-10-17T11:00:00Z or -5m")
+	fs.StringVar(&TimeKey, "time-key", "time", "Key of the pair holding the time of a record, used by --since and --until")
+	fs.BoolVar(&TimeOrdered, "time-ordered", false, "The records are ordered by time: stop reading an input after the first record past --until")
+}
+
 type Size int64
 
 const (
@@ -65,3 +96,109 @@ func (z *Size) String() string {
 }
 
 func (z Size) Type() string { return "int64" }
+
+// DuplicatePolicy is a flag value for a logfmt.DuplicatePolicy.
+type DuplicatePolicy logfmt.DuplicatePolicy
+
+func (d *DuplicatePolicy) Set(s string) error {
+	policy, err := logfmt.ParseDuplicatePolicy(s)
+	if err != nil {
+		return err
+	}
+
+	*d = DuplicatePolicy(policy)
+
+	return nil
+}
+
+func (d *DuplicatePolicy) String() string { return logfmt.DuplicatePolicy(*d).String() }
+
+func (d DuplicatePolicy) Type() string { return "string" }
+
+// PrefixParser is a flag value for a logfmt.PrefixParser, set by its name.
+type PrefixParser struct {
+	Name   string
+	Parser logfmt.PrefixParser
+}
+
+func (p *PrefixParser) Set(s string) error {
+	parser, err := logfmt.ParsePrefixParser(s)
+	if err != nil {
+		return err
+	}
+
+	p.Name = s
+	p.Parser = parser
+
+	return nil
+}
+
+func (p *PrefixParser) String() string {
+	if p.Name == "" {
+		return "none"
+	}
+	return p.Name
+}
+
+func (p PrefixParser) Type() string { return "string" }
+
+// Regexp is a flag value for a regular expression.
+type Regexp struct {
+	*regexp.Regexp
+}
+
+func (r *Regexp) Set(s string) error {
+	re, err := regexp.Compile(s)
+	if err != nil {
+		return err
+	}
+
+	r.Regexp = re
+
+	return nil
+}
+
+func (r *Regexp) String() string {
+	if r.Regexp == nil {
+		return ""
+	}
+	return r.Regexp.String()
+}
+
+func (r Regexp) Type() string { return "regexp" }
+
+// TimeLayouts are the layouts of the times given to a Time flag and of the times of the records.
+var TimeLayouts = []string{time.RFC3339Nano, time.RFC3339, logfmt.EpochLayout}
+
+// Time is a flag value for a time, either absolute with one of TimeLayouts or relative to now
+// with a duration starting with a sign, like -15m.
+type Time struct {
+	time.Time
+}
+
+func (t *Time) Set(s string) error {
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		if d, err := time.ParseDuration(s); err == nil {
+			t.Time = time.Now().Add(d)
+			return nil
+		}
+	}
+
+	tm, err := logfmt.Pair{Value: s}.Time(TimeLayouts...)
+	if err != nil {
+		return fmt.Errorf("invalid time %q, must be a RFC 3339 time, a number of seconds or milliseconds since the Unix epoch or a duration relative to now like -15m", s)
+	}
+
+	t.Time = tm
+
+	return nil
+}
+
+func (t *Time) String() string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+func (t Time) Type() string { return "time" }
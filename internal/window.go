@@ -0,0 +1,82 @@
+package internal
+
+import (
+	"time"
+
+	"github.com/vrischmann/logfmt"
+	"github.com/vrischmann/logfmt/internal/flags"
+)
+
+// TimeWindow selects the records whose time is in a window.
+// The time of a record is the value of its Key pair, parsed with flags.TimeLayouts.
+type TimeWindow struct {
+	// Since and Until are the bounds of the window, both included. A zero time means no bound.
+	Since time.Time
+	Until time.Time
+	Key   string
+	// Ordered is true if the records are ordered by time, so none can be in the window after a record past Until.
+	Ordered bool
+}
+
+// NewTimeWindow returns the time window configured with the shared command line flags,
+// or nil if neither --since nor --until is set.
+func NewTimeWindow() *TimeWindow {
+	if flags.Since.IsZero() && flags.Until.IsZero() {
+		return nil
+	}
+
+	return &TimeWindow{
+		Since:   flags.Since.Time,
+		Until:   flags.Until.Time,
+		Key:     flags.TimeKey,
+		Ordered: flags.TimeOrdered,
+	}
+}
+
+// Check returns true if the current record of the decoder is in the window.
+// Records without a time are never in the window. A nil window contains every record.
+//
+// done is true if the records are ordered and the record is past the end of the window:
+// the rest of the input can be skipped.
+func (w *TimeWindow) Check(dec *logfmt.Decoder) (in, done bool) {
+	if w == nil {
+		return true, false
+	}
+
+	value, ok := w.lookup(dec)
+	if !ok {
+		return false, false
+	}
+	t, err := logfmt.Pair{Key: w.Key, Value: value}.Time(flags.TimeLayouts...)
+	if err != nil {
+		return false, false
+	}
+
+	switch {
+	case !w.Since.IsZero() && t.Before(w.Since):
+		return false, false
+	case !w.Until.IsZero() && t.After(w.Until):
+		return false, w.Ordered
+	default:
+		return true, false
+	}
+}
+
+func (w *TimeWindow) lookup(dec *logfmt.Decoder) (string, bool) {
+	switch {
+	case dec.Prefix != nil || dec.Continuation != nil:
+		// The time can be in the header
+		return dec.Pairs().Lookup(w.Key)
+	case dec.Parser.Duplicates != logfmt.KeepAllDuplicates && dec.Parser.Duplicates != logfmt.FirstDuplicateWins:
+		// The value of the key depends on the other pairs with the same key
+		return dec.Pairs().Lookup(w.Key)
+	}
+
+	// Avoid parsing the whole line when the caller doesn't need its pairs, the first pair with the key is its value
+	for key, value := range dec.Parser.AllBytes(dec.Bytes()) {
+		if string(key) == w.Key {
+			return string(value), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,111 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vrischmann/logfmt"
+)
+
+func TestTimeWindow(t *testing.T) {
+	const data = `time=2026-10-17T09:59:59Z id=1
+time=2026-10-17T10:00:00Z id=2
+id=3
+time=1792231500 id=4
+time=yesterday id=5
+time=2026-10-17T11:00:00Z id=6
+time=2026-10-17T11:00:00.5Z id=7
+time=2026-10-17T10:30:00Z id=8
+`
+
+	since := time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)
+	until := time.Date(2026, 10, 17, 11, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		window *TimeWindow
+		exp    []string
+	}{
+		{nil, []string{"1", "2", "3", "4", "5", "6", "7", "8"}},
+		{&TimeWindow{Since: since, Key: "time"}, []string{"2", "4", "6", "7", "8"}},
+		{&TimeWindow{Until: until, Key: "time"}, []string{"1", "2", "4", "6", "8"}},
+		{&TimeWindow{Since: since, Until: until, Key: "time"}, []string{"2", "4", "6", "8"}},
+		{&TimeWindow{Since: since, Until: until, Key: "time", Ordered: true}, []string{"2", "4", "6"}},
+		{&TimeWindow{Since: since, Key: "ts"}, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			dec := logfmt.NewDecoder(strings.NewReader(data))
+
+			var res []string
+			for dec.Next() {
+				in, done := tc.window.Check(dec)
+				if done {
+					break
+				}
+				if in {
+					res = append(res, dec.Pairs().Get("id"))
+				}
+			}
+			require.NoError(t, dec.Err())
+			require.Equal(t, tc.exp, res)
+		})
+	}
+}
+
+func TestTimeWindowPrefix(t *testing.T) {
+	dec := logfmt.NewDecoder(strings.NewReader(`2026-10-17T10:00:01Z stdout F id=1
+2026-10-17T09:00:01Z stdout F id=2
+`))
+	dec.Prefix = logfmt.CRIPrefix
+
+	window := &TimeWindow{
+		Since: time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC),
+		Key:   logfmt.PrefixTimestampKey,
+	}
+
+	var res []string
+	for dec.Next() {
+		if in, _ := window.Check(dec); in {
+			res = append(res, dec.Pairs().Get("id"))
+		}
+	}
+	require.Equal(t, []string{"1"}, res)
+}
+
+func TestTimeWindowDuplicates(t *testing.T) {
+	const data = `time=2026-10-17T09:00:00Z time=2026-10-17T10:00:01Z id=1
+time=2026-10-17T10:00:01Z time=2026-10-17T09:00:00Z id=2
+`
+
+	window := &TimeWindow{
+		Since: time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC),
+		Key:   "time",
+	}
+
+	testCases := []struct {
+		policy logfmt.DuplicatePolicy
+		exp    []string
+	}{
+		{logfmt.KeepAllDuplicates, []string{"2"}},
+		{logfmt.FirstDuplicateWins, []string{"2"}},
+		{logfmt.LastDuplicateWins, []string{"1"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.policy.String(), func(t *testing.T) {
+			dec := logfmt.NewDecoder(strings.NewReader(data))
+			dec.Parser.Duplicates = tc.policy
+
+			var res []string
+			for dec.Next() {
+				if in, _ := window.Check(dec); in {
+					res = append(res, dec.Pairs().Get("id"))
+				}
+			}
+			require.Equal(t, tc.exp, res)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package logfmt
+
+import "iter"
+
+// All returns an iterator over the key-value pairs of a log line, parsed according to the logfmt rules.
+// It is a convenience function which does the same as PairParser.All(line).
+func All(line string) iter.Seq2[string, string] {
+	var parser PairParser
+	return parser.All(line)
+}
+
+// All returns an iterator over the key-value pairs of a log line.
+//
+// Pairs are parsed one at a time while iterating, so stopping early skips parsing the rest of the line.
+// Keys and values are substrings of line; nothing is allocated except for quoted values containing escape sequences.
+// A bare key is yielded with an empty value.
+//
+// In strict mode the iteration stops at the first syntax error. The duplicate policy and ReplaceInvalidUTF8
+// are ignored: every pair is yielded as it appears in the line.
+func (p *PairParser) All(line string) iter.Seq2[string, string] {
+	return func(yield func(string, string) bool) {
+		for i := 0; ; {
+			span, next, ok := nextSpan(p, line, i)
+			if !ok {
+				return
+			}
+			i = next
+
+			key := line[span.Key.Start:span.Key.End]
+			value := line[span.Value.Start:span.Value.End]
+			if span.Decoded != nil {
+				value = string(span.Decoded)
+			}
+
+			if !yield(key, value) {
+				return
+			}
+		}
+	}
+}
+
+// AllBytes works like All but iterates over the pairs of a line given as a byte slice.
+// Keys and values are subslices of line unless they must be decoded, see PairSpan.Decoded.
+func (p *PairParser) AllBytes(line []byte) iter.Seq2[[]byte, []byte] {
+	return func(yield func([]byte, []byte) bool) {
+		for i := 0; ; {
+			span, next, ok := nextSpan(p, line, i)
+			if !ok {
+				return
+			}
+			i = next
+
+			if !yield(span.KeyBytes(line), span.ValueBytes(line)) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package logfmt
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAll(t *testing.T) {
+	testCases := []string{
+		"",
+		"ab=cd",
+		"foo=bar 1=2    a=b   ",
+		`str="foo bar baz" esc="a\"bé" tags= debug`,
+		`a=b msg="foo \q" c=d =e`,
+		`a=b msg="foo bar`,
+		`a=1 a=2`,
+	}
+
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			var exp, res, resBytes Pairs
+			for _, pair := range Split(tc) {
+				exp = append(exp, Pair{Key: pair.Key, Value: pair.Value})
+			}
+
+			for key, value := range All(tc) {
+				res = append(res, Pair{Key: key, Value: value})
+			}
+			require.Equal(t, exp, res)
+
+			var parser PairParser
+			for key, value := range parser.AllBytes([]byte(tc)) {
+				resBytes = append(resBytes, Pair{Key: string(key), Value: string(value)})
+			}
+			require.Equal(t, exp, resBytes)
+		})
+	}
+}
+
+func TestAllStrict(t *testing.T) {
+	parser := PairParser{Strict: true}
+
+	var keys []string
+	for key := range parser.All(`a=b c=d =e f=g`) {
+		keys = append(keys, key)
+	}
+	require.Equal(t, []string{"a", "c"}, keys)
+}
+
+func TestAllStopsEarly(t *testing.T) {
+	line := "a=1 b=2 " + strings.Repeat(`c="\"" `, 1000)
+
+	var value string
+	allocs := testing.AllocsPerRun(100, func() {
+		for k, v := range All(line) {
+			if k == "b" {
+				value = v
+				break
+			}
+		}
+	})
+	require.Equal(t, 0.0, allocs)
+	require.Equal(t, "2", value)
+}
+
+func BenchmarkAllFirstKey(b *testing.B) {
+	line := "house=foobar " + strings.Repeat("foo=bar ", 1000)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for key := range All(line) {
+			if key == "house" {
+				break
+			}
+		}
+	}
+}
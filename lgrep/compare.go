@@ -0,0 +1,129 @@
+package lgrep
+
+import (
+	"cmp"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/vrischmann/logfmt"
+)
+
+type operandKind int
+
+const (
+	noOperand operandKind = iota
+	numberOperand
+	durationOperand
+	timeOperand
+)
+
+// operandTimeLayouts are the layouts of a time operand.
+var operandTimeLayouts = []string{time.RFC3339Nano, time.RFC3339}
+
+// valueTimeLayouts are the layouts of a value compared with a time operand.
+var valueTimeLayouts = []string{time.RFC3339Nano, time.RFC3339, logfmt.EpochLayout}
+
+// operand is the value of a comparison query parsed as a number, a duration or a time.
+type operand struct {
+	kind operandKind
+
+	isInt    bool
+	intValue int64
+	float    float64
+	duration time.Duration
+	time     time.Time
+}
+
+// parseOperand parses s as a number, a duration or a time, in that order.
+// The kind of the result is noOperand if s is none of them.
+func parseOperand(s string) operand {
+	var res operand
+
+	if f, ok := parseFloat(s); ok {
+		res.kind, res.float = numberOperand, f
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			res.isInt, res.intValue = true, i
+		}
+		return res
+	}
+
+	pair := logfmt.Pair{Value: s}
+	if d, err := pair.Duration(); err == nil {
+		res.kind, res.duration = durationOperand, d
+		return res
+	}
+	if t, err := pair.Time(operandTimeLayouts...); err == nil {
+		res.kind, res.time = timeOperand, t
+		return res
+	}
+
+	return res
+}
+
+// parseFloat parses s as a finite number: NaN and infinities can't be ordered with other numbers.
+func parseFloat(s string) (float64, bool) {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, false
+	}
+	return f, true
+}
+
+// compareValue parses the value like the operand and compares them.
+// The boolean is false if the value can't be parsed.
+//
+// Integers are compared exactly, otherwise numbers are compared as floats.
+// A value compared with a time can also be a number of seconds or milliseconds since the Unix epoch.
+func (o *operand) compareValue(value string) (int, bool) {
+	switch o.kind {
+	case numberOperand:
+		if o.isInt {
+			if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+				return cmp.Compare(i, o.intValue), true
+			}
+		}
+		if f, ok := parseFloat(value); ok {
+			return cmp.Compare(f, o.float), true
+		}
+
+	case durationOperand:
+		if d, err := (logfmt.Pair{Value: value}).Duration(); err == nil {
+			return cmp.Compare(d, o.duration), true
+		}
+
+	case timeOperand:
+		if t, err := (logfmt.Pair{Value: value}).Time(valueTimeLayouts...); err == nil {
+			return t.Compare(o.time), true
+		}
+	}
+
+	return 0, false
+}
+
+// compare compares the value with the value of the query, as numbers, durations or times depending on the
+// operand of the query.
+//
+// If the value can't be parsed like the operand it never matches, except with != where it is compared as a string instead:
+// this way key!=value always matches the lines where key has another value.
+func (q *Query) compare(value string) bool {
+	res, ok := q.operand.compareValue(value)
+
+	switch q.comparison {
+	case notEqualOperator:
+		if !ok {
+			return value != q.value
+		}
+		return res != 0
+	case lessOperator:
+		return ok && res < 0
+	case lessOrEqualOperator:
+		return ok && res <= 0
+	case greaterOperator:
+		return ok && res > 0
+	case greaterOrEqualOperator:
+		return ok && res >= 0
+	default:
+		return false
+	}
+}
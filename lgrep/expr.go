@@ -0,0 +1,418 @@
+package lgrep
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/vrischmann/logfmt"
+)
+
+// Expr is a boolean expression of queries, like `(status=500 or status=503) and not path~health`.
+//
+// It is compiled once by ParseExpr into a tree of queries combined with and, or and not.
+// Like a Query an Expr is not safe for concurrent use.
+type Expr struct {
+	root    exprNode
+	queries []*Query
+}
+
+// ParseExpr parses a boolean expression of queries.
+//
+// A query has the same forms as the ones given to ExtractQueries, like key=value, key~value, key=~regexp, key>=number,
+// key? or !key.
+// The value can be quoted, for example msg="connection refused"; an unquoted value ends at the first whitespace or
+// at a closing parenthesis without a matching opening one.
+//
+// Queries are combined with the operators not, and, or, from the highest to the lowest precedence,
+// and grouped with parentheses. Two queries next to each other without an operator are combined with and.
+func ParseExpr(s string) (*Expr, error) {
+	p := exprParser{input: s}
+
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	if p.tok.kind == tokenEOF {
+		return nil, fmt.Errorf("lgrep: empty expression")
+	}
+
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokenEOF {
+		return nil, p.errorf("unexpected %s", p.tok)
+	}
+
+	return &Expr{root: root, queries: p.queries}, nil
+}
+
+// Match returns true if the line matches the expression, see Query.Match.
+func (e *Expr) Match(line string) bool {
+	return e.root.eval(func(qry *Query) bool { return qry.Match(line) })
+}
+
+// MatchBytes works like Match but takes the line as a byte slice, see Query.MatchBytes.
+func (e *Expr) MatchBytes(line []byte) bool {
+	return e.root.eval(func(qry *Query) bool { return qry.MatchBytes(line) })
+}
+
+// MatchPairs works like Match but takes pairs already parsed, see Query.MatchPairs.
+func (e *Expr) MatchPairs(pairs logfmt.Pairs) bool {
+	return e.root.eval(func(qry *Query) bool { return qry.MatchPairs(pairs) })
+}
+
+// SetDuplicatePolicy sets the policy used when parsing lines with duplicate keys for all queries.
+func (e *Expr) SetDuplicatePolicy(policy logfmt.DuplicatePolicy) {
+	for _, qry := range e.queries {
+		qry.SetDuplicatePolicy(policy)
+	}
+}
+
+// String returns the expression with every operator written as a function, like and(a=b, not(c~d)).
+func (e *Expr) String() string {
+	return e.root.String()
+}
+
+// exprNode is a node of the tree of an Expr. eval calls matchFn to match the queries.
+type exprNode interface {
+	eval(matchFn func(qry *Query) bool) bool
+	String() string
+}
+
+type queryNode struct {
+	qry *Query
+}
+
+func (n queryNode) eval(matchFn func(qry *Query) bool) bool { return matchFn(n.qry) }
+func (n queryNode) String() string                          { return n.qry.String() }
+
+type notNode struct {
+	node exprNode
+}
+
+func (n notNode) eval(matchFn func(qry *Query) bool) bool { return !n.node.eval(matchFn) }
+func (n notNode) String() string                          { return "not(" + n.node.String() + ")" }
+
+type andNode []exprNode
+
+func (n andNode) eval(matchFn func(qry *Query) bool) bool {
+	for _, node := range n {
+		if !node.eval(matchFn) {
+			return false
+		}
+	}
+	return true
+}
+
+func (n andNode) String() string { return joinNodes("and", n) }
+
+type orNode []exprNode
+
+func (n orNode) eval(matchFn func(qry *Query) bool) bool {
+	for _, node := range n {
+		if node.eval(matchFn) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n orNode) String() string { return joinNodes("or", n) }
+
+func joinNodes(op string, nodes []exprNode) string {
+	var sb strings.Builder
+	sb.WriteString(op)
+	sb.WriteByte('(')
+	for i, node := range nodes {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(node.String())
+	}
+	sb.WriteByte(')')
+	return sb.String()
+}
+
+//
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenLeftParen
+	tokenRightParen
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenQuery
+)
+
+type token struct {
+	kind   tokenKind
+	offset int
+	text   string
+	qry    Query
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of expression"
+	}
+	return strconv.Quote(t.text)
+}
+
+// exprParser is a recursive descent parser with one token of lookahead. The grammar is:
+//
+//	or      = and { "or" and }
+//	and     = not { [ "and" ] not }
+//	not     = "not" not | primary
+//	primary = "(" or ")" | query
+type exprParser struct {
+	input   string
+	pos     int
+	tok     token
+	queries []*Query
+}
+
+func (p *exprParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("lgrep: invalid expression at offset %d: %s", p.tok.offset, fmt.Sprintf(format, args...))
+}
+
+func (p *exprParser) parseOr() (exprNode, error) {
+	node, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	res := orNode{node}
+	for p.tok.kind == tokenOr {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		node, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, node)
+	}
+
+	if len(res) == 1 {
+		return res[0], nil
+	}
+	return res, nil
+}
+
+func (p *exprParser) parseAnd() (exprNode, error) {
+	node, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	res := andNode{node}
+	for {
+		switch p.tok.kind {
+		case tokenAnd:
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+		case tokenNot, tokenLeftParen, tokenQuery:
+			// implicit and
+		default:
+			if len(res) == 1 {
+				return res[0], nil
+			}
+			return res, nil
+		}
+
+		node, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, node)
+	}
+}
+
+func (p *exprParser) parseNot() (exprNode, error) {
+	if p.tok.kind != tokenNot {
+		return p.parsePrimary()
+	}
+
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	node, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	return notNode{node}, nil
+}
+
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	switch p.tok.kind {
+	case tokenLeftParen:
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok.kind != tokenRightParen {
+			return nil, p.errorf("expected \")\", got %s", p.tok)
+		}
+		return node, p.next()
+
+	case tokenQuery:
+		qry := new(Query)
+		*qry = p.tok.qry
+		p.queries = append(p.queries, qry)
+		return queryNode{qry}, p.next()
+
+	default:
+		return nil, p.errorf("expected a query, got %s", p.tok)
+	}
+}
+
+// next reads the next token into p.tok.
+func (p *exprParser) next() error {
+	for p.pos < len(p.input) && isSpace(p.input[p.pos]) {
+		p.pos++
+	}
+
+	start := p.pos
+	p.tok = token{offset: start}
+
+	if p.pos >= len(p.input) {
+		p.tok.kind = tokenEOF
+		return nil
+	}
+
+	switch p.input[p.pos] {
+	case '(':
+		p.pos++
+		p.tok.kind, p.tok.text = tokenLeftParen, "("
+		return nil
+	case ')':
+		p.pos++
+		p.tok.kind, p.tok.text = tokenRightParen, ")"
+		return nil
+	}
+
+	// A query can start with the absent operator, !key
+	absent := strings.HasPrefix(p.input[p.pos:], absentOperator) && !strings.HasPrefix(p.input[p.pos:], notEqualOperator)
+	if absent {
+		p.pos += len(absentOperator)
+	}
+
+	// Either a keyword or a query: read until the operator of the query
+	keyStart := p.pos
+	for p.pos < len(p.input) && !strings.ContainsRune(operatorChars+"() \t\r\n", rune(p.input[p.pos])) {
+		p.pos++
+	}
+	key := p.input[keyStart:p.pos]
+
+	var operator, value string
+	switch {
+	case absent:
+		operator = absentOperator
+
+	case p.pos >= len(p.input) || !strings.ContainsRune(operatorChars, rune(p.input[p.pos])):
+		p.tok.text = key
+		switch strings.ToLower(key) {
+		case "and":
+			p.tok.kind = tokenAnd
+		case "or":
+			p.tok.kind = tokenOr
+		case "not":
+			p.tok.kind = tokenNot
+		default:
+			return p.errorf("invalid query %q, no operator", key)
+		}
+		return nil
+
+	default:
+		var ok bool
+		operator, _, ok = cutOperator(p.input[p.pos:])
+		if !ok {
+			return p.errorf("invalid operator in query %q", p.input[start:])
+		}
+		p.pos += len(operator)
+	}
+	if key == "" {
+		return p.errorf("query without a key")
+	}
+
+	switch operator {
+	case presentOperator, absentOperator:
+		// There's no value, the query must end here
+		if p.pos < len(p.input) && !isSpace(p.input[p.pos]) && p.input[p.pos] != '(' && p.input[p.pos] != ')' {
+			return p.errorf("invalid character %q after query %q", p.input[p.pos], p.input[start:p.pos])
+		}
+
+	default:
+		var err error
+		if value, err = p.readValue(); err != nil {
+			return err
+		}
+	}
+
+	p.tok.kind = tokenQuery
+	p.tok.text = p.input[start:p.pos]
+
+	var err error
+	p.tok.qry, err = makeQuery(key, operator, value)
+	if err != nil {
+		return p.errorf("%v", err)
+	}
+
+	return nil
+}
+
+// readValue reads the value of a query, quoted or not.
+func (p *exprParser) readValue() (string, error) {
+	start := p.pos
+
+	if p.pos < len(p.input) && p.input[p.pos] == '"' {
+		p.pos++
+		for p.pos < len(p.input) && p.input[p.pos] != '"' {
+			if p.input[p.pos] == '\\' {
+				p.pos++
+			}
+			p.pos++
+		}
+		if p.pos >= len(p.input) {
+			return "", p.errorf("unterminated quoted value")
+		}
+		p.pos++
+
+		value, err := strconv.Unquote(p.input[start:p.pos])
+		if err != nil {
+			return "", p.errorf("invalid quoted value %s", p.input[start:p.pos])
+		}
+		return value, nil
+	}
+
+	// Parentheses are part of the value as long as they are balanced, like in a regexp group
+	depth := 0
+loop:
+	for ; p.pos < len(p.input); p.pos++ {
+		switch c := p.input[p.pos]; {
+		case isSpace(c):
+			break loop
+		case c == '(':
+			depth++
+		case c == ')':
+			if depth == 0 {
+				break loop
+			}
+			depth--
+		}
+	}
+
+	return p.input[start:p.pos], nil
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
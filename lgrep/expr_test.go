@@ -0,0 +1,114 @@
+package lgrep
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vrischmann/logfmt"
+)
+
+func TestParseExpr(t *testing.T) {
+	testCases := []struct {
+		input string
+		exp   string
+	}{
+		{`a=b`, `a=b`},
+		{`a=b and c~d`, `and(a=b, c~d)`},
+		{`a=b c~d`, `and(a=b, c~d)`},
+		{`a=b or c=d and e=f`, `or(a=b, and(c=d, e=f))`},
+		{`a=b and c=d or e=f`, `or(and(a=b, c=d), e=f)`},
+		{`not a=b and c=d`, `and(not(a=b), c=d)`},
+		{`not not a=b`, `not(not(a=b))`},
+		{`(status=500 or status=503) and not path~health`, `and(or(status=500, status=503), not(path~health))`},
+		{`((a=b))`, `a=b`},
+		{`a=b OR (c=d AND NOT e=f)`, `or(a=b, and(c=d, not(e=f)))`},
+		{`city=~(Paris|Lyon) or (city=~^San)`, `or(city=~(Paris|Lyon), city=~^San)`},
+		{`msg="connection refused" or msg="a \"b\" (c"`, `or(msg=connection refused, msg=a "b" (c)`},
+		{`a= or b~`, `or(a="", b~)`},
+		{`status>=500 and (latency_ms>100 or level!=info)`, `and(status>=500, or(latency_ms>100, level!=info))`},
+		{`elapsed>2s and time>=2026-10-17T10:00:00Z`, `and(elapsed>2s, time>=2026-10-17T10:00:00Z)`},
+		{`!a and b? or c=""`, `or(and(!a, b?), c="")`},
+		{`not !a (b?)`, `and(not(!a), b?)`},
+		{`(!a)`, `!a`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			expr, err := ParseExpr(tc.input)
+			require.NoError(t, err)
+			require.Equal(t, tc.exp, expr.String())
+		})
+	}
+}
+
+func TestParseExprErrors(t *testing.T) {
+	testCases := []struct {
+		input string
+		exp   string
+	}{
+		{``, `lgrep: empty expression`},
+		{`a=b and`, `lgrep: invalid expression at offset 7: expected a query, got end of expression`},
+		{`(a=b`, `lgrep: invalid expression at offset 4: expected ")", got end of expression`},
+		{`a=b)`, `lgrep: invalid expression at offset 3: unexpected ")"`},
+		{`a=b or foo`, `lgrep: invalid expression at offset 7: invalid query "foo", no operator`},
+		{`=b`, `lgrep: invalid expression at offset 0: query without a key`},
+		{`a=~(`, "lgrep: invalid expression at offset 0: error parsing regexp: missing closing ): `(`"},
+		{`msg="foo`, `lgrep: invalid expression at offset 0: unterminated quoted value`},
+		{`a=b or c!d`, `lgrep: invalid expression at offset 7: invalid operator in query "c!d"`},
+		{`a?b`, `lgrep: invalid expression at offset 0: invalid character 'b' after query "a?"`},
+		{`!a=b`, `lgrep: invalid expression at offset 0: invalid character '=' after query "!a"`},
+		{`! a`, `lgrep: invalid expression at offset 0: query without a key`},
+		{`?`, `lgrep: invalid expression at offset 0: query without a key`},
+		{`a>b`, `lgrep: invalid expression at offset 0: "b" is not a number, a duration or a time, it can't be compared with >`},
+		{`a<NaN`, `lgrep: invalid expression at offset 0: "NaN" is not a number, a duration or a time, it can't be compared with <`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			_, err := ParseExpr(tc.input)
+			require.EqualError(t, err, tc.exp)
+		})
+	}
+}
+
+func TestExprMatch(t *testing.T) {
+	const expr = `(status=500 or status=503) and not path~health`
+
+	testCases := []struct {
+		input string
+		exp   bool
+	}{
+		{`status=500 path=/api/users`, true},
+		{`status=503 path=/api/users`, true},
+		{`status=503`, true},
+		{`status=200 path=/api/users`, false},
+		{`status=500 path=/healthz`, false},
+		{`path=/api/users`, false},
+	}
+
+	e, err := ParseExpr(expr)
+	require.NoError(t, err)
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			require.Equal(t, tc.exp, e.Match(tc.input))
+			require.Equal(t, tc.exp, e.MatchBytes([]byte(tc.input)))
+			require.Equal(t, tc.exp, e.MatchPairs(logfmt.Split(tc.input)))
+		})
+	}
+}
+
+func TestExprSetDuplicatePolicy(t *testing.T) {
+	e, err := ParseExpr(`a=1 and not b=2`)
+	require.NoError(t, err)
+
+	const line = `a=1 a=3 b=1 b=2`
+
+	require.False(t, e.Match(line))
+
+	e.SetDuplicatePolicy(logfmt.FirstDuplicateWins)
+	require.True(t, e.Match(line))
+
+	e.SetDuplicatePolicy(logfmt.LastDuplicateWins)
+	require.False(t, e.Match(line))
+}
@@ -1,7 +1,10 @@
 package lgrep
 
 import (
+	"bytes"
+	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/vrischmann/logfmt"
@@ -13,82 +16,233 @@ type Query struct {
 	fuzzy  bool
 	regexp *regexp.Regexp
 
-	keyWithEquals string // used only in the fast failout
-	parser        logfmt.PairParser
-	pairs         logfmt.Pairs
+	// comparison is the operator of a comparison query like key>=value and operand its parsed value, see compare.
+	comparison string
+	operand    operand
+
+	// anyValue is set for the queries matching only the presence of the key, key? and !key.
+	// absent is set for !key: the query matches if no pair has the key.
+	anyValue bool
+	absent   bool
+
+	needle string // used only in the fast bailout: a line without it can't have a matching pair
+	parser logfmt.PairParser
+	pairs  logfmt.Pairs
+
+	// used only by MatchBytes, initialized on first use
+	needleBytes []byte
+	valueBytes  []byte
+	spans       []logfmt.PairSpan
 }
 
 func newQuery(key string) Query {
 	return Query{
-		key:           key,
-		keyWithEquals: key + "=",
-		pairs:         make(logfmt.Pairs, 64),
+		key:    key,
+		needle: key + "=",
+		pairs:  make(logfmt.Pairs, 64),
 	}
 }
 
 func (q *Query) Copy() Query {
 	tmp := Query{
-		key:           q.key,
-		keyWithEquals: q.keyWithEquals,
-		value:         q.value,
-		fuzzy:         q.fuzzy,
-		pairs:         make(logfmt.Pairs, len(q.pairs)),
+		key:        q.key,
+		needle:     q.needle,
+		value:      q.value,
+		fuzzy:      q.fuzzy,
+		comparison: q.comparison,
+		operand:    q.operand,
+		anyValue:   q.anyValue,
+		absent:     q.absent,
+		pairs:      make(logfmt.Pairs, len(q.pairs)),
 	}
+	tmp.parser.Duplicates = q.parser.Duplicates
 	if q.regexp != nil {
 		tmp.regexp = q.regexp.Copy()
 	}
 	return tmp
 }
 
+// String returns the query as it is written in a query argument, like key=value.
+func (q *Query) String() string {
+	switch {
+	case q.absent:
+		return absentOperator + q.key
+	case q.anyValue:
+		return q.key + presentOperator
+	case q.regexp != nil:
+		return q.key + regexOperator + q.regexp.String()
+	case q.comparison != "":
+		return q.key + q.comparison + q.value
+	case q.fuzzy:
+		return q.key + fuzzyOperator + q.value
+	case q.value == "":
+		return q.key + strictOperator + `""`
+	default:
+		return q.key + strictOperator + q.value
+	}
+}
+
 func (q *Query) MatchKeys(keys []string) bool {
 	for _, key := range keys {
 		if key == q.key {
-			return true
+			return !q.absent
 		}
 	}
-	return false
+	return q.absent
 }
 
+// Match returns true if the line has a pair with the query key whose value matches the query,
+// or for a !key query if the line has no pair with the key.
+//
+// If the key appears multiple times in the line, which occurrences are considered depends on the
+// duplicate policy set with SetDuplicatePolicy: by default any occurrence can match.
 func (q *Query) Match(line string) bool {
+	return q.matchLine(line) != q.absent
+}
+
+func (q *Query) matchLine(line string) bool {
 	// Fast bailout: if the key is not in the line there's no need to parse the line
-	if !strings.Contains(line, q.keyWithEquals) {
+	if !strings.Contains(line, q.needle) {
 		return false
 	}
 
-	pairs := q.parser.SplitInto(line, q.pairs)
+	// Note it's possible that the needle is a part of another key, for example:
+	// needle     foobar=
+	// the key   afoobar=
+	//
+	// In that case the check `strings.Contains` would match above but the actual key isn't present
+	// therefore no pair would match.
 
-	var pair *logfmt.Pair
-	for i := range pairs {
-		v := &pairs[i]
+	if !q.streamable() {
+		q.pairs = q.parser.SplitInto(line, q.pairs)
+		return q.matchPairs(q.pairs)
+	}
 
-		if v.Key == q.key {
-			pair = v
-			break
+	// Pairs are parsed one at a time, the rest of the line is skipped as soon as the result is known.
+	for key, value := range q.parser.All(line) {
+		if key != q.key {
+			continue
+		}
+		if q.matchValue(value) {
+			return true
+		}
+		if q.parser.Duplicates == logfmt.FirstDuplicateWins {
+			return false
 		}
 	}
 
-	if pair == nil {
-		// It's possible that `keyWithEquals` is a part of another key, for example:
-		// keyWithEquals    foobar=
-		// the key         afoobar=
-		//
-		// In that cas the check `strings.Contains` would match above but the actual key isn't present
-		// therefore the pair would be nil.
+	return false
+}
+
+// MatchBytes works like Match but takes the line as a byte slice.
+// The line is parsed with logfmt.PairParser.AllBytes or SplitBytesInto so no copy of the line, its keys or its values is made.
+func (q *Query) MatchBytes(line []byte) bool {
+	return q.matchLineBytes(line) != q.absent
+}
+
+func (q *Query) matchLineBytes(line []byte) bool {
+	if q.needleBytes == nil {
+		q.needleBytes = []byte(q.needle)
+		q.valueBytes = []byte(q.value)
+	}
+
+	// Fast bailout: if the key is not in the line there's no need to parse the line
+	if !bytes.Contains(line, q.needleBytes) {
+		return false
+	}
+
+	if !q.streamable() {
+		q.spans = q.parser.SplitBytesInto(line, q.spans)
+
+		for i := range q.spans {
+			span := &q.spans[i]
+			if string(span.KeyBytes(line)) == q.key && q.matchValueBytes(span.ValueBytes(line)) {
+				return true
+			}
+		}
 		return false
 	}
 
+	for key, value := range q.parser.AllBytes(line) {
+		if string(key) != q.key {
+			continue
+		}
+		if q.matchValueBytes(value) {
+			return true
+		}
+		if q.parser.Duplicates == logfmt.FirstDuplicateWins {
+			return false
+		}
+	}
+
+	return false
+}
+
+// MatchPairs works like Match but takes pairs already parsed, for example by a logfmt.Decoder.
+// The duplicate policy is not applied: it must have been applied when parsing the pairs.
+func (q *Query) MatchPairs(pairs logfmt.Pairs) bool {
+	return q.matchPairs(pairs) != q.absent
+}
+
+func (q *Query) matchPairs(pairs logfmt.Pairs) bool {
+	for i := range pairs {
+		pair := &pairs[i]
+		if pair.Key == q.key && q.matchValue(pair.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+// streamable returns true if the pairs can be matched while parsing the line, before knowing all its pairs.
+// That's not the case when the last occurrence of a key wins or when duplicates are merged.
+func (q *Query) streamable() bool {
+	return q.parser.Duplicates == logfmt.KeepAllDuplicates || q.parser.Duplicates == logfmt.FirstDuplicateWins
+}
+
+func (q *Query) matchValue(value string) bool {
 	switch {
+	case q.anyValue:
+		return true
+
+	case q.comparison != "":
+		return q.compare(value)
+
 	case q.fuzzy:
-		return strings.Contains(pair.Value, q.value)
+		return strings.Contains(value, q.value)
 
 	case q.regexp != nil:
-		return q.regexp.MatchString(pair.Value)
+		return q.regexp.MatchString(value)
 
 	default:
-		return pair.Value == q.value
+		return value == q.value
 	}
 }
 
+func (q *Query) matchValueBytes(value []byte) bool {
+	switch {
+	case q.anyValue:
+		return true
+
+	case q.comparison != "":
+		return q.compare(string(value))
+
+	case q.fuzzy:
+		return bytes.Contains(value, q.valueBytes)
+
+	case q.regexp != nil:
+		return q.regexp.Match(value)
+
+	default:
+		return string(value) == q.value
+	}
+}
+
+// SetDuplicatePolicy sets the policy used when parsing lines with duplicate keys.
+func (q *Query) SetDuplicatePolicy(policy logfmt.DuplicatePolicy) {
+	q.parser.Duplicates = policy
+}
+
 type Queries []Query
 
 func (q Queries) Copy() Queries {
@@ -99,6 +253,13 @@ func (q Queries) Copy() Queries {
 	return tmp
 }
 
+// SetDuplicatePolicy sets the policy used when parsing lines with duplicate keys for all queries.
+func (q Queries) SetDuplicatePolicy(policy logfmt.DuplicatePolicy) {
+	for i := range q {
+		q[i].SetDuplicatePolicy(policy)
+	}
+}
+
 func (q Queries) MatchKeys(keys []string, opt *QueryOption) bool {
 	if opt != nil && opt.Reverse {
 		return !q.matchKeys(keys)
@@ -122,12 +283,12 @@ type QueryOption struct {
 	Or      bool
 }
 
-func (q Queries) match(line string, opt *QueryOption) bool {
+func (q Queries) match(opt *QueryOption, matchFn func(qry *Query) bool) bool {
 	switch {
 	case opt != nil && opt.Or:
 		for i := range q {
 			qry := &q[i]
-			if qry.Match(line) {
+			if matchFn(qry) {
 				return true
 			}
 		}
@@ -137,7 +298,7 @@ func (q Queries) match(line string, opt *QueryOption) bool {
 		res := true
 		for i := range q {
 			qry := &q[i]
-			if !qry.Match(line) {
+			if !matchFn(qry) {
 				res = false
 			}
 		}
@@ -146,48 +307,156 @@ func (q Queries) match(line string, opt *QueryOption) bool {
 }
 
 func (q Queries) Match(line string, opt *QueryOption) bool {
+	res := q.match(opt, func(qry *Query) bool { return qry.Match(line) })
 	if opt != nil && opt.Reverse {
-		return !q.match(line, opt)
+		return !res
 	}
 
-	return q.match(line, opt)
+	return res
+}
+
+// MatchPairs works like Match but takes pairs already parsed, see Query.MatchPairs.
+func (q Queries) MatchPairs(pairs logfmt.Pairs, opt *QueryOption) bool {
+	res := q.match(opt, func(qry *Query) bool { return qry.MatchPairs(pairs) })
+	if opt != nil && opt.Reverse {
+		return !res
+	}
+
+	return res
+}
+
+// MatchBytes works like Match but takes the line as a byte slice, see Query.MatchBytes.
+func (q Queries) MatchBytes(line []byte, opt *QueryOption) bool {
+	res := q.match(opt, func(qry *Query) bool { return qry.MatchBytes(line) })
+	if opt != nil && opt.Reverse {
+		return !res
+	}
+
+	return res
 }
 
 const (
-	regexOperator  = "=~"
-	fuzzyOperator  = "~"
-	strictOperator = "="
+	regexOperator          = "=~"
+	fuzzyOperator          = "~"
+	strictOperator         = "="
+	notEqualOperator       = "!="
+	lessOperator           = "<"
+	lessOrEqualOperator    = "<="
+	greaterOperator        = ">"
+	greaterOrEqualOperator = ">="
+	presentOperator        = "?"
+	absentOperator         = "!"
 )
 
-func ExtractQueries(args []string) Queries {
-	var res Queries
+// operators are all the operators of a query, the longest first so that cutOperator finds <= before <.
+var operators = []string{
+	regexOperator,
+	notEqualOperator,
+	lessOrEqualOperator,
+	greaterOrEqualOperator,
+	fuzzyOperator,
+	strictOperator,
+	lessOperator,
+	greaterOperator,
+	presentOperator,
+}
 
-	for _, arg := range args {
-		var qry Query
+// operatorChars are the characters an operator can start with.
+const operatorChars = "=~!<>?"
 
-		switch {
-		case strings.Contains(arg, regexOperator):
-			tokens := strings.Split(arg, regexOperator)
-			qry = newQuery(tokens[0])
-			qry.regexp = regexp.MustCompile(tokens[1])
+// cutOperator returns the operator at the start of s and the rest of s.
+func cutOperator(s string) (operator, rest string, ok bool) {
+	for _, op := range operators {
+		if strings.HasPrefix(s, op) {
+			return op, s[len(op):], true
+		}
+	}
+	return "", s, false
+}
+
+// splitQuery splits a query argument like key>=value at its operator, which is the first one in the argument.
+// The absent operator is a prefix, !key, and the present operator a suffix, key?.
+// A quoted value, like key="", is unquoted.
+func splitQuery(arg string) (key, operator, value string, ok bool) {
+	if key, ok := strings.CutPrefix(arg, absentOperator); ok && key != "" && !strings.ContainsAny(key, operatorChars) {
+		return key, absentOperator, "", true
+	}
+
+	pos := strings.IndexAny(arg, operatorChars)
+	if pos == -1 {
+		return "", "", "", false
+	}
+
+	operator, value, ok = cutOperator(arg[pos:])
+	if operator == presentOperator && (pos == 0 || value != "") {
+		return "", "", "", false
+	}
+	if strings.HasPrefix(value, `"`) {
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+	}
+
+	return arg[:pos], operator, value, ok
+}
+
+// makeQuery returns the query matching the key with the operator and value.
+func makeQuery(key, operator, value string) (Query, error) {
+	qry := newQuery(key)
+	qry.value = value
+
+	switch operator {
+	case regexOperator:
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return qry, err
+		}
+		qry.regexp = re
 
-		case strings.Contains(arg, fuzzyOperator):
-			tokens := strings.Split(arg, fuzzyOperator)
-			qry = newQuery(tokens[0])
-			qry.value = tokens[1]
-			qry.fuzzy = true
+	case fuzzyOperator:
+		qry.fuzzy = true
 
-		case strings.Contains(arg, strictOperator):
-			tokens := strings.Split(arg, strictOperator)
-			qry = newQuery(tokens[0])
-			qry.value = tokens[1]
+	case strictOperator:
+		if value == "" {
+			// A bare key has an empty value too but no '=' after it
+			qry.needle = key
+		}
+
+	case presentOperator, absentOperator:
+		qry.needle = key
+		qry.anyValue = true
+		qry.absent = operator == absentOperator
 
-		default:
-			return res
+	case notEqualOperator, lessOperator, lessOrEqualOperator, greaterOperator, greaterOrEqualOperator:
+		qry.comparison = operator
+		qry.operand = parseOperand(value)
+
+		if qry.operand.kind == noOperand && operator != notEqualOperator {
+			return qry, fmt.Errorf("%q is not a number, a duration or a time, it can't be compared with %s", value, operator)
+		}
+	}
+
+	return qry, nil
+}
+
+// ExtractQueries parses the queries at the start of args, up to the first argument which is not a query.
+// It returns an error if a query is invalid, for example if its regexp can't be compiled.
+func ExtractQueries(args []string) (Queries, error) {
+	var res Queries
+
+	for _, arg := range args {
+		key, operator, value, ok := splitQuery(arg)
+		if !ok {
+			break
+		}
+
+		qry, err := makeQuery(key, operator, value)
+		if err != nil {
+			return nil, fmt.Errorf("lgrep: invalid query %q: %w", arg, err)
 		}
 
 		res = append(res, qry)
 	}
 
-	return res
+	return res, nil
 }
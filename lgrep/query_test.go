@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	"github.com/vrischmann/logfmt"
 )
 
 func BenchmarkQueryFuzzyPresent(b *testing.B) {
@@ -54,6 +55,29 @@ func BenchmarkQueryNotFuzzyNotPresent(b *testing.B) {
 	}
 }
 
+func BenchmarkQueryNotFuzzyPresentFirst(b *testing.B) {
+	q := newQuery("house")
+	q.value = "foobar"
+	line := "house=foobar " + strings.Repeat("foo=bar ", 1000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !q.Match(line) {
+			b.Fatal("should match")
+		}
+	}
+}
+
+func BenchmarkQueryBytesNotFuzzyPresent(b *testing.B) {
+	q := newQuery("house")
+	line := []byte(strings.Repeat("foo=bar ", 1000) + strings.Repeat("house=foobar ", 1000))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = q.MatchBytes(line)
+	}
+}
+
 func mkq(key, value string) Query {
 	q := newQuery(key)
 	q.value = value
@@ -120,6 +144,9 @@ func TestQueryMatch(t *testing.T) {
 		t.Run("", func(t *testing.T) {
 			res := tc.qry.Match(tc.input)
 			require.Equal(t, tc.exp, res)
+
+			res = tc.qry.MatchBytes([]byte(tc.input))
+			require.Equal(t, tc.exp, res)
 		})
 	}
 }
@@ -255,6 +282,9 @@ func TestQueriesMatch(t *testing.T) {
 	for _, tc := range testCases {
 		res := tc.q.Match(tc.input, tc.opt)
 		require.Equal(t, tc.exp, res)
+
+		res = tc.q.MatchBytes([]byte(tc.input), tc.opt)
+		require.Equal(t, tc.exp, res)
 	}
 }
 
@@ -325,3 +355,207 @@ func TestQueriesMatchKeys(t *testing.T) {
 		require.Equal(t, tc.exp, res)
 	}
 }
+
+func TestQueryMatchDuplicates(t *testing.T) {
+	const line = "status=200 path=/ status=500"
+
+	testCases := []struct {
+		policy logfmt.DuplicatePolicy
+		qry    Query
+		exp    bool
+	}{
+		{logfmt.KeepAllDuplicates, mkq("status", "200"), true},
+		{logfmt.KeepAllDuplicates, mkq("status", "500"), true},
+		{logfmt.FirstDuplicateWins, mkq("status", "200"), true},
+		{logfmt.FirstDuplicateWins, mkq("status", "500"), false},
+		{logfmt.LastDuplicateWins, mkq("status", "200"), false},
+		{logfmt.LastDuplicateWins, mkq("status", "500"), true},
+		{logfmt.MergeDuplicates, mkq("status", `["200","500"]`), true},
+		{logfmt.MergeDuplicates, mkfq("status", "500"), true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.policy.String(), func(t *testing.T) {
+			qs := Queries{tc.qry}
+			qs.SetDuplicatePolicy(tc.policy)
+			require.Equal(t, tc.exp, qs.Match(line, nil))
+			require.Equal(t, tc.exp, qs.Copy().Match(line, nil))
+			require.Equal(t, tc.exp, qs.MatchBytes([]byte(line), nil))
+		})
+	}
+}
+
+func TestQueriesMatchPairs(t *testing.T) {
+	pairs := logfmt.Pairs{
+		{Key: "host", Value: "web1"},
+		{Key: "app", Value: "api"},
+		{Key: "level", Value: "info"},
+	}
+
+	require.True(t, Queries{mkq("app", "api"), mkq("level", "info")}.MatchPairs(pairs, nil))
+	require.False(t, Queries{mkq("app", "api"), mkq("level", "error")}.MatchPairs(pairs, nil))
+	require.True(t, Queries{mkfq("host", "web"), mkq("level", "error")}.MatchPairs(pairs, &QueryOption{Or: true}))
+	require.False(t, Queries{mkq("app", "api")}.MatchPairs(pairs, &QueryOption{Reverse: true}))
+}
+
+func TestExtractQueries(t *testing.T) {
+	qs, err := ExtractQueries([]string{"a=b", "c~d", "e=~^f", "g!=h", "i<1", "j<=2", "k>3.5", "l>=-4", "m=n=o", "p?", "!q", `r=""`, `s="t u"`, "file.log"})
+	require.NoError(t, err)
+
+	var res []string
+	for i := range qs {
+		res = append(res, qs[i].String())
+	}
+	require.Equal(t, []string{"a=b", "c~d", "e=~^f", "g!=h", "i<1", "j<=2", "k>3.5", "l>=-4", "m=n=o", "p?", "!q", `r=""`, "s=t u"}, res)
+
+	for _, arg := range []string{"?", "!", "a?b", "file?.log"} {
+		qs, err := ExtractQueries([]string{arg})
+		require.NoError(t, err)
+		require.Empty(t, qs, arg)
+	}
+
+	for _, arg := range []string{"a=~(", "a<b", "a>=", "a>1x", "a>2026-10-17"} {
+		_, err := ExtractQueries([]string{arg})
+		require.Error(t, err, arg)
+	}
+}
+
+func TestQueryMatchComparison(t *testing.T) {
+	testCases := []struct {
+		input string
+		query string
+		exp   bool
+	}{
+		{"status=500", "status>=500", true},
+		{"status=503", "status>=500", true},
+		{"status=404", "status>=500", false},
+		{"status=500", "status>500", false},
+		{"status=500", "status<=500", true},
+		{"status=500", "status<500", false},
+		{"latency_ms=9.5", "latency_ms<10", true},
+		{"latency_ms=1e1", "latency_ms<10", false},
+		{"bytes=2097152", "bytes>1048576", true},
+		{"bytes=-1", "bytes>1048576", false},
+		{"ratio=0.5", "ratio>0.25", true},
+		{"ratio=0.5", "ratio<=.5", true},
+		// integers beyond the precision of a float are compared exactly
+		{"id=9007199254740993", "id>9007199254740992", true},
+		// a value which is not a number doesn't match
+		{"status=abc", "status>=500", false},
+		{"status=", "status<500", false},
+		{"status", "status<500", false},
+		{"latency=10ms", "latency<100", false},
+		// neither does NaN nor an infinity
+		{"latency=NaN", "latency<10", false},
+		{"latency=NaN", "latency>=10", false},
+		{"latency=+Inf", "latency>10", false},
+		{"latency=-Inf", "latency<10", false},
+		{"latency=infinity", "latency>10", false},
+		// except with != which compares it as a string
+		{"status=500", "status!=500", false},
+		{"status=500.0", "status!=500", false},
+		{"status=404", "status!=500", true},
+		{"status=abc", "status!=500", true},
+		{"level=info", "level!=error", true},
+		{"level=error", "level!=error", false},
+		// the key must be present
+		{"foo=bar", "status!=500", false},
+		{"foo=1", "status<500", false},
+		// any occurrence of the key can match
+		{"status=200 status=500", "status>=500", true},
+		// durations
+		{"elapsed=2.5s", "elapsed>2s", true},
+		{"elapsed=1m", "elapsed>2s", true},
+		{"elapsed=1500ms", "elapsed>2s", false},
+		{"elapsed=2s", "elapsed>=2000ms", true},
+		{"elapsed=2", "elapsed>1s", false},
+		{"elapsed=slow", "elapsed>1s", false},
+		{"elapsed=2s", "elapsed!=2000ms", false},
+		{"elapsed=slow", "elapsed!=2s", true},
+		// times
+		{"time=2026-10-17T10:00:00Z", "time>=2026-10-17T10:00:00Z", true},
+		{"time=2026-10-17T09:59:59.999Z", "time>=2026-10-17T10:00:00Z", false},
+		{"time=2026-10-17T12:00:00+02:00", "time>=2026-10-17T10:00:00Z", true},
+		{"time=2026-10-17T10:00:00.5Z", "time<2026-10-17T10:00:00.6Z", true},
+		{"time=1792231200", "time>=2026-10-17T10:00:00Z", true},
+		{"time=1792231199", "time>=2026-10-17T10:00:00Z", false},
+		{"time=1792231200000", "time>=2026-10-17T10:00:00Z", true},
+		{"time=1792231199999", "time>=2026-10-17T10:00:00Z", false},
+		{"time=1792231199.9", "time<2026-10-17T10:00:00Z", true},
+		{"time=yesterday", "time<2026-10-17T10:00:00Z", false},
+		{"time=2026-10-17", "time<2026-10-18T10:00:00Z", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input+" "+tc.query, func(t *testing.T) {
+			qs, err := ExtractQueries([]string{tc.query})
+			require.NoError(t, err)
+			require.Len(t, qs, 1)
+
+			require.Equal(t, tc.exp, qs[0].Match(tc.input))
+			require.Equal(t, tc.exp, qs[0].MatchBytes([]byte(tc.input)))
+			require.Equal(t, tc.exp, qs[0].MatchPairs(logfmt.Split(tc.input)))
+			require.Equal(t, tc.exp, qs.Copy()[0].Match(tc.input))
+		})
+	}
+}
+
+func TestQueryMatchPresence(t *testing.T) {
+	testCases := []struct {
+		input string
+		query string
+		exp   bool
+	}{
+		{"city=Lyon", "city?", true},
+		{"city=", "city?", true},
+		{"city", "city?", true},
+		{"foo=bar city", "city?", true},
+		{"foo=bar", "city?", false},
+		{"foocity=Lyon", "city?", false},
+		{"city_name=Lyon", "city?", false},
+		{"msg=city", "city?", false},
+		//
+		{"city=Lyon", "!city", false},
+		{"city", "!city", false},
+		{"foo=bar", "!city", true},
+		{"foocity=Lyon", "!city", true},
+		{"msg=city", "!city", true},
+		{"", "!city", true},
+		//
+		{"city=", `city=""`, true},
+		{`city=""`, `city=""`, true},
+		{"city", `city=""`, true},
+		{"foo=bar city", `city=""`, true},
+		{"city=Lyon", `city=""`, false},
+		{"city=Lyon", "city=", false},
+		{"city", "city=", true},
+		{"foo=bar", `city=""`, false},
+		{"foo=city", `city=""`, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input+" "+tc.query, func(t *testing.T) {
+			qs, err := ExtractQueries([]string{tc.query})
+			require.NoError(t, err)
+			require.Len(t, qs, 1)
+
+			require.Equal(t, tc.exp, qs[0].Match(tc.input))
+			require.Equal(t, tc.exp, qs[0].MatchBytes([]byte(tc.input)))
+			require.Equal(t, tc.exp, qs[0].MatchPairs(logfmt.Split(tc.input)))
+			require.Equal(t, tc.exp, qs.Copy()[0].Match(tc.input))
+
+			// the line is parsed at once instead of pair by pair with this policy
+			qs.SetDuplicatePolicy(logfmt.LastDuplicateWins)
+			require.Equal(t, tc.exp, qs[0].Match(tc.input))
+			require.Equal(t, tc.exp, qs[0].MatchBytes([]byte(tc.input)))
+		})
+	}
+}
+
+func TestQueryMatchKeysAbsent(t *testing.T) {
+	qs, err := ExtractQueries([]string{"!city"})
+	require.NoError(t, err)
+
+	require.False(t, qs[0].MatchKeys([]string{"foo", "city"}))
+	require.True(t, qs[0].MatchKeys([]string{"foo"}))
+}
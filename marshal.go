@@ -0,0 +1,345 @@
+package logfmt
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Marshal returns the logfmt encoding of v, which must be a struct or a pointer to a struct.
+//
+// Each exported field becomes a pair. The key is the field name unless overridden with a struct tag:
+//
+//	Name    string        `logfmt:"name"`           // key is "name"
+//	Elapsed time.Duration `logfmt:"elapsed,omitempty"` // omitted if zero
+//	Secret  string        `logfmt:"-"`              // ignored
+//
+// Supported field types are strings, integers, floats, booleans, time.Duration, pointers to these and any type
+// implementing encoding.TextMarshaler, like time.Time. Nil pointers are omitted.
+// Fields of embedded structs are treated as if they were fields of the outer struct; a struct embedding itself,
+// directly or not, contributes its fields once.
+func Marshal(v interface{}) ([]byte, error) {
+	pairs, err := MarshalPairs(v)
+	if err != nil {
+		return nil, err
+	}
+	return pairs.AppendFormat(nil), nil
+}
+
+// MarshalPairs works like Marshal but returns the pairs instead of formatting them.
+func MarshalPairs(v interface{}) (Pairs, error) {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() {
+		return nil, errors.New("logfmt: Marshal(nil)")
+	}
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, errors.New("logfmt: Marshal(nil)")
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("logfmt: Marshal(non-struct %s)", rv.Type())
+	}
+	if !rv.CanAddr() {
+		// Make the value addressable so that pointer receivers of encoding.TextMarshaler are found
+		tmp := reflect.New(rv.Type()).Elem()
+		tmp.Set(rv)
+		rv = tmp
+	}
+
+	fields := cachedStructFields(rv.Type())
+
+	pairs := make(Pairs, 0, len(fields))
+	for _, f := range fields {
+		fv, ok := fieldByIndex(rv, f.index, false)
+		if !ok {
+			continue
+		}
+		if f.omitEmpty && fv.IsZero() {
+			continue
+		}
+		if fv.Kind() == reflect.Ptr && fv.IsNil() {
+			// Nothing to write: an empty value couldn't be unmarshalled back into most types
+			continue
+		}
+
+		value, err := marshalValue(fv)
+		if err != nil {
+			return nil, fmt.Errorf("logfmt: cannot marshal field %q: %w", f.name, err)
+		}
+
+		pairs = append(pairs, Pair{Key: f.name, Value: value})
+	}
+
+	return pairs, nil
+}
+
+// Unmarshal parses a logfmt line and stores the pairs in the struct pointed to by v.
+//
+// The mapping between keys and fields follows the same rules as Marshal. Keys without a matching field are ignored,
+// and if a key appears multiple times the last value wins. A bare key sets a boolean field to true.
+func Unmarshal(line []byte, v interface{}) error {
+	var parser PairParser
+	return UnmarshalPairs(parser.Split(string(line)), v)
+}
+
+// UnmarshalPairs works like Unmarshal but takes already parsed pairs.
+func UnmarshalPairs(pairs Pairs, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("logfmt: Unmarshal(non-pointer or nil %T)", v)
+	}
+	rv = rv.Elem()
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("logfmt: Unmarshal(pointer to non-struct %s)", rv.Type())
+	}
+
+	fields := cachedStructFields(rv.Type())
+
+	for _, pair := range pairs {
+		f, ok := fields.byName(pair.Key)
+		if !ok {
+			continue
+		}
+
+		fv, _ := fieldByIndex(rv, f.index, true)
+		if err := unmarshalValue(fv, pair); err != nil {
+			return fmt.Errorf("logfmt: cannot unmarshal %q into field %q of type %s: %w", pair.Value, f.name, fv.Type(), err)
+		}
+	}
+
+	return nil
+}
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+func marshalValue(v reflect.Value) (string, error) {
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+
+	switch {
+	case v.Type().Implements(textMarshalerType):
+		data, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(data), err
+	case v.CanAddr() && v.Addr().Type().Implements(textMarshalerType):
+		data, err := v.Addr().Interface().(encoding.TextMarshaler).MarshalText()
+		return string(data), err
+	case v.Type() == durationType:
+		return time.Duration(v.Int()).String(), nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()), nil
+	default:
+		return "", fmt.Errorf("unsupported type %s", v.Type())
+	}
+}
+
+func unmarshalValue(v reflect.Value, pair Pair) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(pair.Value))
+	}
+
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(pair.Value)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(pair.Value)
+
+	case reflect.Bool:
+		if pair.Bare {
+			v.SetBool(true)
+			return nil
+		}
+		b, err := strconv.ParseBool(pair.Value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(pair.Value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(pair.Value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(pair.Value, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	return nil
+}
+
+// fieldByIndex returns the field of v at the given index path.
+// Nil embedded pointers are allocated if `alloc` is true, otherwise the field is reported as not found.
+func fieldByIndex(v reflect.Value, index []int, alloc bool) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !alloc {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+type structField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+type structFields []structField
+
+func (f structFields) byName(name string) (structField, bool) {
+	for _, field := range f {
+		if field.name == name {
+			return field, true
+		}
+	}
+	return structField{}, false
+}
+
+var fieldCache sync.Map // map[reflect.Type]structFields
+
+func cachedStructFields(t reflect.Type) structFields {
+	if f, ok := fieldCache.Load(t); ok {
+		return f.(structFields)
+	}
+	f, _ := fieldCache.LoadOrStore(t, typeFields(t, nil, make(map[reflect.Type]bool)))
+	return f.(structFields)
+}
+
+// typeFields returns the fields of the struct type t.
+// Fields of embedded structs are added after the fields of t itself so that they can't shadow them.
+//
+// visiting holds the types embedding t: a struct embedding itself, directly or not, is only visited once.
+func typeFields(t reflect.Type, index []int, visiting map[reflect.Type]bool) structFields {
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	var (
+		fields   structFields
+		embedded []reflect.StructField
+	)
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+
+		tag := sf.Tag.Get("logfmt")
+		if tag == "-" {
+			continue
+		}
+		name, omitEmpty := tag, false
+		if pos := strings.IndexByte(tag, ','); pos != -1 {
+			name, omitEmpty = tag[:pos], tag[pos+1:] == "omitempty"
+		}
+
+		ft := sf.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+
+		if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct && !reflect.PtrTo(ft).Implements(textUnmarshalerType) {
+			// Pointers to unexported structs can't be allocated when unmarshaling
+			if sf.PkgPath == "" || sf.Type.Kind() != reflect.Ptr {
+				embedded = append(embedded, sf)
+			}
+			continue
+		}
+		if sf.PkgPath != "" {
+			continue
+		}
+
+		if name == "" {
+			name = sf.Name
+		}
+
+		fields = append(fields, structField{
+			name:      name,
+			index:     appendIndex(index, i),
+			omitEmpty: omitEmpty,
+		})
+	}
+
+	for _, sf := range embedded {
+		ft := sf.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+
+		if visiting[ft] {
+			continue
+		}
+
+		for _, f := range typeFields(ft, appendIndex(index, sf.Index[0]), visiting) {
+			if _, ok := fields.byName(f.name); ok {
+				continue
+			}
+			fields = append(fields, f)
+		}
+	}
+
+	return fields
+}
+
+func appendIndex(index []int, i int) []int {
+	res := make([]int, len(index)+1)
+	copy(res, index)
+	res[len(index)] = i
+	return res
+}
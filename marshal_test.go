@@ -0,0 +1,184 @@
+package logfmt
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type marshalBase struct {
+	Service string `logfmt:"service"`
+	Host    string `logfmt:"host,omitempty"`
+}
+
+type MarshalExtra struct {
+	Trace string `logfmt:"trace_id"`
+}
+
+type marshalRecord struct {
+	marshalBase
+	*MarshalExtra
+
+	Time     time.Time     `logfmt:"time"`
+	Level    string        `logfmt:"level"`
+	Msg      string        `logfmt:"msg"`
+	Status   int           `logfmt:"status"`
+	Bytes    uint64        `logfmt:"bytes,omitempty"`
+	Ratio    float64       `logfmt:"ratio"`
+	Cached   bool          `logfmt:"cached"`
+	Elapsed  time.Duration `logfmt:"elapsed"`
+	IP       net.IP        `logfmt:"ip,omitempty"`
+	User     *string       `logfmt:"user,omitempty"`
+	Secret   string        `logfmt:"-"`
+	Untagged string
+}
+
+func TestMarshal(t *testing.T) {
+	user := "vincent"
+
+	rec := marshalRecord{
+		marshalBase:  marshalBase{Service: "api"},
+		MarshalExtra: &MarshalExtra{Trace: "abcd"},
+		Time:         time.Date(2026, 10, 17, 12, 0, 1, 0, time.UTC),
+		Level:        "info",
+		Msg:          "request done",
+		Status:       200,
+		Ratio:        0.5,
+		Cached:       true,
+		Elapsed:      1500 * time.Millisecond,
+		IP:           net.ParseIP("10.0.0.1"),
+		User:         &user,
+		Secret:       "hunter2",
+		Untagged:     "x",
+	}
+
+	data, err := Marshal(rec)
+	require.NoError(t, err)
+	require.Equal(t, `time=2026-10-17T12:00:01Z level=info msg="request done" status=200 ratio=0.5 cached=true elapsed=1.5s ip=10.0.0.1 user=vincent Untagged=x service=api trace_id=abcd`, string(data))
+}
+
+func TestMarshalOmitEmpty(t *testing.T) {
+	data, err := Marshal(&marshalRecord{})
+	require.NoError(t, err)
+	require.Equal(t, `time=0001-01-01T00:00:00Z level="" msg="" status=0 ratio=0 cached=false elapsed=0s Untagged="" service=""`, string(data))
+}
+
+func TestUnmarshal(t *testing.T) {
+	const line = `time=2026-10-17T12:00:01.5Z level=info msg="request done" status=200 bytes=1024 ratio=0.5 cached elapsed=1.5s ip=10.0.0.1 user=vincent service=api trace_id=abcd unknown=1 Secret=foo`
+
+	var rec marshalRecord
+	require.NoError(t, Unmarshal([]byte(line), &rec))
+
+	require.Equal(t, time.Date(2026, 10, 17, 12, 0, 1, 500000000, time.UTC), rec.Time)
+	require.Equal(t, "info", rec.Level)
+	require.Equal(t, "request done", rec.Msg)
+	require.Equal(t, 200, rec.Status)
+	require.Equal(t, uint64(1024), rec.Bytes)
+	require.Equal(t, 0.5, rec.Ratio)
+	require.True(t, rec.Cached)
+	require.Equal(t, 1500*time.Millisecond, rec.Elapsed)
+	require.Equal(t, "10.0.0.1", rec.IP.String())
+	require.NotNil(t, rec.User)
+	require.Equal(t, "vincent", *rec.User)
+	require.Equal(t, "api", rec.Service)
+	require.NotNil(t, rec.MarshalExtra)
+	require.Equal(t, "abcd", rec.Trace)
+	require.Equal(t, "", rec.Secret)
+}
+
+func TestUnmarshalRoundTrip(t *testing.T) {
+	rec := marshalRecord{
+		marshalBase: marshalBase{Service: "api", Host: "web-1"},
+		Time:        time.Date(2026, 10, 17, 12, 0, 1, 0, time.UTC),
+		Msg:         `quoted "message" with = sign`,
+		Status:      -1,
+		Elapsed:     time.Minute,
+	}
+
+	data, err := Marshal(rec)
+	require.NoError(t, err)
+
+	var res marshalRecord
+	require.NoError(t, Unmarshal(data, &res))
+	require.Equal(t, rec, res)
+}
+
+func TestUnmarshalRoundTripPointers(t *testing.T) {
+	type record struct {
+		N    *int       `logfmt:"n"`
+		Time *time.Time `logfmt:"time"`
+	}
+
+	n := 0
+	now := time.Date(2026, 10, 17, 12, 0, 1, 0, time.UTC)
+
+	testCases := []struct {
+		rec record
+		exp string
+	}{
+		{record{}, ``},
+		{record{N: &n}, `n=0`},
+		{record{N: &n, Time: &now}, `n=0 time=2026-10-17T12:00:01Z`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.exp, func(t *testing.T) {
+			data, err := Marshal(tc.rec)
+			require.NoError(t, err)
+			require.Equal(t, tc.exp, string(data))
+
+			var res record
+			require.NoError(t, Unmarshal(data, &res))
+			require.Equal(t, tc.rec, res)
+		})
+	}
+}
+
+type marshalCycle struct {
+	A int `logfmt:"a"`
+	*marshalCycle
+	*MarshalCycleB
+}
+
+type MarshalCycleB struct {
+	B int `logfmt:"b"`
+	*marshalCycle
+}
+
+func TestMarshalEmbeddedCycle(t *testing.T) {
+	data, err := Marshal(marshalCycle{A: 1, MarshalCycleB: &MarshalCycleB{B: 2}})
+	require.NoError(t, err)
+	require.Equal(t, `a=1 b=2`, string(data))
+
+	var res marshalCycle
+	require.NoError(t, Unmarshal([]byte(`a=3 b=4`), &res))
+	require.Equal(t, 3, res.A)
+	require.Equal(t, 4, res.B)
+}
+
+func TestMarshalErrors(t *testing.T) {
+	_, err := Marshal(nil)
+	require.EqualError(t, err, "logfmt: Marshal(nil)")
+
+	_, err = Marshal((*marshalRecord)(nil))
+	require.EqualError(t, err, "logfmt: Marshal(nil)")
+
+	_, err = Marshal(1)
+	require.EqualError(t, err, "logfmt: Marshal(non-struct int)")
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	var rec marshalRecord
+
+	err := Unmarshal([]byte("status=abc"), &rec)
+	require.EqualError(t, err, `logfmt: cannot unmarshal "abc" into field "status" of type int: strconv.ParseInt: parsing "abc": invalid syntax`)
+
+	err = Unmarshal([]byte("status=1"), rec)
+	require.Error(t, err)
+
+	var i int
+	err = Unmarshal([]byte("status=1"), &i)
+	require.Error(t, err)
+}
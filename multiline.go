@@ -0,0 +1,39 @@
+package logfmt
+
+import (
+	"regexp"
+)
+
+// DefaultContinuationKey is the key of the pair holding the continuation lines of a record
+// unless changed with Decoder.ContinuationKey.
+const DefaultContinuationKey = "stack"
+
+// ContinuationFunc reports whether a line continues the previous record instead of starting a new one,
+// like the lines of a stack trace following the record logging an error.
+type ContinuationFunc func(line []byte) bool
+
+// IndentedContinuation treats lines starting with a space or a tab as continuation lines,
+// which is how Java stack traces are printed.
+func IndentedContinuation(line []byte) bool {
+	return len(line) > 0 && (line[0] == ' ' || line[0] == '\t')
+}
+
+// RecordStartContinuation returns a ContinuationFunc which treats every line not matching start as a continuation line.
+// For example ^time= works with Go panics, whose goroutine headers are not indented.
+func RecordStartContinuation(start *regexp.Regexp) ContinuationFunc {
+	return func(line []byte) bool {
+		return !start.Match(line)
+	}
+}
+
+// AnyContinuation returns a ContinuationFunc which treats a line as a continuation line if any of the functions does.
+func AnyContinuation(fns ...ContinuationFunc) ContinuationFunc {
+	return func(line []byte) bool {
+		for _, fn := range fns {
+			if fn(line) {
+				return true
+			}
+		}
+		return false
+	}
+}
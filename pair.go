@@ -3,16 +3,33 @@ package logfmt
 import (
 	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 // Pair contains a key and value of a logfmt line.
+//
+// A key followed by '=' and nothing else, like `key=`, has an empty Value.
+// A key appearing on its own, like `key`, is a bare key: Value is empty too but Bare is true.
 type Pair struct {
 	Key   string
 	Value string
+	Bare  bool
+
+	// Raw is the original text of the pair, preceded by the whitespace separating it from the previous pair.
+	// It is only set by a PairParser with KeepRaw and used by Pairs.AppendFormatPreserve.
+	// It stays empty if part of the text is lost when parsing the pair, like a quoted value with an invalid
+	// escape sequence, so that the text is never written back.
+	Raw string
 }
 
 // Pairs is a collection of key-value pairs.
 // Pairs implements sort.Interface so it is sortable.
+//
+// A key can appear multiple times in a line, so it can appear multiple times in Pairs too.
+// The lookup methods (Index, Get, Lookup, ToMap) only consider the first occurrence of a key;
+// use Values to get all of them. The mutation methods which work on a key (Set, Delete, Rename) apply to
+// every occurrence.
 type Pairs []Pair
 
 func (p Pairs) Len() int {
@@ -27,20 +44,143 @@ func (p Pairs) Swap(i, j int) {
 	p[i], p[j] = p[j], p[i]
 }
 
+// Index returns the index of the first pair with the key, or -1 if there is none.
+func (p Pairs) Index(key string) int {
+	for i := range p {
+		if p[i].Key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// Has returns true if there is a pair with the key.
+func (p Pairs) Has(key string) bool {
+	return p.Index(key) != -1
+}
+
+// Get returns the value of the first pair with the key, or an empty string if there is none.
+func (p Pairs) Get(key string) string {
+	value, _ := p.Lookup(key)
+	return value
+}
+
+// Lookup returns the value of the first pair with the key.
+// The boolean is false if there is no such pair.
+func (p Pairs) Lookup(key string) (string, bool) {
+	i := p.Index(key)
+	if i == -1 {
+		return "", false
+	}
+	return p[i].Value, true
+}
+
+// Values returns the values of all pairs with the key, in order.
+func (p Pairs) Values(key string) []string {
+	var res []string
+	for _, pair := range p {
+		if pair.Key == key {
+			res = append(res, pair.Value)
+		}
+	}
+	return res
+}
+
+// Set sets the value of the key and returns the resulting pairs.
+//
+// The first pair with the key is updated in place and any other pair with the same key is removed.
+// If there is no pair with the key a new one is appended.
+func (p Pairs) Set(key, value string) Pairs {
+	i := p.Index(key)
+	if i == -1 {
+		return append(p, Pair{Key: key, Value: value})
+	}
+
+	p[i].Value = value
+	p[i].Bare = false
+
+	res := p[:i+1]
+	for _, pair := range p[i+1:] {
+		if pair.Key != key {
+			res = append(res, pair)
+		}
+	}
+	return res
+}
+
+// Delete removes all pairs with the key and returns the resulting pairs.
+// The pairs are modified in place.
+func (p Pairs) Delete(key string) Pairs {
+	return p.Drop(key)
+}
+
+// Rename renames the key of all pairs with the key `from` to `to`.
+// The pairs are modified in place.
+func (p Pairs) Rename(from, to string) {
+	for i := range p {
+		if p[i].Key == from {
+			p[i].Key = to
+		}
+	}
+}
+
+// Keep removes all pairs whose key is not in `keys` and returns the resulting pairs.
+// The order of the pairs is preserved and they are modified in place.
+func (p Pairs) Keep(keys ...string) Pairs {
+	return p.filter(keys, true)
+}
+
+// Drop removes all pairs whose key is in `keys` and returns the resulting pairs.
+// The order of the pairs is preserved and they are modified in place.
+func (p Pairs) Drop(keys ...string) Pairs {
+	return p.filter(keys, false)
+}
+
+func (p Pairs) filter(keys []string, keep bool) Pairs {
+	res := p[:0]
+	for _, pair := range p {
+		if containsKey(keys, pair.Key) == keep {
+			res = append(res, pair)
+		}
+	}
+	return res
+}
+
+func containsKey(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// ToMap returns a map of the keys to their value.
+// Like Get, if a key appears multiple times only its first value is kept.
+func (p Pairs) ToMap() map[string]string {
+	res := make(map[string]string, len(p))
+	for _, pair := range p {
+		if _, ok := res[pair.Key]; !ok {
+			res[pair.Key] = pair.Value
+		}
+	}
+	return res
+}
+
 // AppendFormat formats the pairs in a logfmt compatible way and appends the formatted strings to b.
 // It returns the resulting b.
 //
+// Values are quoted when needed so that parsing the result with Split gives back the same pairs.
+// Characters which can't appear in a key (whitespace, '=', '"' and control characters) are replaced with '_'.
+//
 // Note that the pairs are appended as they come, there's no reordering.
 // If you want the pairs to be sorted you have to call `sort.Sort` on the slice first.
 func (p Pairs) AppendFormat(b []byte) []byte {
 	for i, pair := range p {
-		b = append(b, pair.Key...)
-		b = append(b, '=')
-
-		if needsQuoting(pair.Value) {
-			b = append(b, strconv.Quote(pair.Value)...)
-		} else {
-			b = append(b, pair.Value...)
+		b = appendKey(b, pair.Key)
+		if !pair.Bare {
+			b = append(b, '=')
+			b = appendValue(b, pair.Value)
 		}
 
 		if i+1 < len(p) {
@@ -51,8 +191,129 @@ func (p Pairs) AppendFormat(b []byte) []byte {
 	return b
 }
 
+// AppendFormatPreserve works like AppendFormat but the pairs which were not modified since they were parsed by
+// a PairParser with KeepRaw are appended exactly as they were in the original line, quoting and whitespace included.
+// Modified pairs, and pairs without Raw text, are formatted like AppendFormat does.
+//
+// This way removing or changing some pairs of a line leaves the other pairs untouched, except the ones whose text
+// couldn't be parsed without loss, see Pair.Raw.
+func (p Pairs) AppendFormatPreserve(b []byte) []byte {
+	start := len(b)
+
+	for _, pair := range p {
+		if pair.Raw == "" || !pair.rawMatches() {
+			if len(b) > start {
+				b = append(b, ' ')
+			}
+			b = Pairs{pair}.AppendFormat(b)
+			continue
+		}
+
+		raw := pair.Raw
+		switch {
+		case len(b) == start:
+			raw = strings.TrimLeft(raw, " ")
+		case raw[0] != ' ' && b[len(b)-1] != '"':
+			// In the original line the pair followed a quoted value, which isn't the case anymore.
+			b = append(b, ' ')
+		}
+		b = append(b, raw...)
+	}
+
+	return b
+}
+
+// FormatPreserve formats the pairs like AppendFormatPreserve does.
+func (p Pairs) FormatPreserve() string {
+	return string(p.AppendFormatPreserve(nil))
+}
+
+// rawMatches returns true if parsing Raw gives the key and value of the pair.
+func (p *Pair) rawMatches() bool {
+	raw := strings.TrimLeft(p.Raw, " ")
+	if p.Bare {
+		return raw == p.Key
+	}
+
+	// An unquoted value without double quotes is parsed as is.
+	if len(raw) == len(p.Key)+1+len(p.Value) &&
+		strings.HasPrefix(raw, p.Key) && raw[len(p.Key)] == '=' && strings.HasSuffix(raw, p.Value) &&
+		!strings.Contains(p.Value, `"`) {
+		return true
+	}
+
+	pairs := Split(raw)
+
+	return len(pairs) == 1 && !pairs[0].Bare && pairs[0].Key == p.Key && pairs[0].Value == p.Value
+}
+
+// appendSeparator appends a space to b if it is not empty.
+func appendSeparator(b []byte) []byte {
+	if len(b) > 0 {
+		b = append(b, ' ')
+	}
+	return b
+}
+
+// appendKey appends the key to b, replacing the characters not allowed in a key.
+func appendKey(b []byte, key string) []byte {
+	for i := 0; i < len(key); {
+		r, n := utf8.DecodeRuneInString(key[i:])
+		if invalidKeyRune(r, n) {
+			b = append(b, '_')
+		} else {
+			b = append(b, key[i:i+n]...)
+		}
+		i += n
+	}
+	return b
+}
+
+func invalidKeyRune(r rune, size int) bool {
+	switch {
+	case r == utf8.RuneError && size == 1:
+		return true
+	case r < utf8.RuneSelf:
+		return r <= ' ' || r == '=' || r == '"' || r == 0x7f
+	default:
+		return !unicode.IsPrint(r)
+	}
+}
+
+// appendValue appends the value to b, quoting it if necessary.
+func appendValue(b []byte, value string) []byte {
+	if needsQuoting(value) {
+		return strconv.AppendQuote(b, value)
+	}
+	return append(b, value...)
+}
+
+// needsQuoting returns true if the value can't be written as is.
+// This is the case for the empty string, values containing whitespace, '=', '"', control characters
+// or anything that is not printable valid UTF-8.
 func needsQuoting(s string) bool {
-	return strings.ContainsAny(s, " \"=")
+	if s == "" {
+		return true
+	}
+
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			if c <= ' ' || c == '=' || c == '"' || c == 0x7f {
+				return true
+			}
+			i++
+			continue
+		}
+
+		r, n := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && n == 1 || !unicode.IsPrint(r) {
+			return true
+		}
+		i += n
+	}
+
+	return false
 }
 
 // Format formats the pairs in a logfmt compatible way.
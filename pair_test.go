@@ -2,6 +2,7 @@ package logfmt
 
 import (
 	"sort"
+	"strconv"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -14,12 +15,12 @@ func TestPairsSort(t *testing.T) {
 	}{
 		{
 			Pairs{
-				{"foo", "a b c d e f"},
-				{"bar", "baz"},
+				{Key: "foo", Value: "a b c d e f"},
+				{Key: "bar", Value: "baz"},
 			},
 			Pairs{
-				{"bar", "baz"},
-				{"foo", "a b c d e f"},
+				{Key: "bar", Value: "baz"},
+				{Key: "foo", Value: "a b c d e f"},
 			},
 		},
 	}
@@ -37,29 +38,36 @@ func TestPairsFormat(t *testing.T) {
 	}{
 		{
 			Pairs{
-				{"foo", "a b c d e f"},
-				{"bar", "baz"},
+				{Key: "foo", Value: "a b c d e f"},
+				{Key: "bar", Value: "baz"},
 			},
 			`bar=baz foo="a b c d e f"`,
 		},
 		{
 			Pairs{
-				{"a", `"bar"baz"`},
+				{Key: "a", Value: `"bar"baz"`},
 			},
 			`a="\"bar\"baz\""`,
 		},
 		{
 			Pairs{
-				{"abcd", "a=e"},
+				{Key: "abcd", Value: "a=e"},
 			},
 			`abcd="a=e"`,
 		},
 		{
 			Pairs{
-				{"abcd", ""},
-				{"bar", "baz"},
+				{Key: "abcd", Value: ""},
+				{Key: "bar", Value: "baz"},
 			},
-			`abcd= bar=baz`,
+			`abcd="" bar=baz`,
+		},
+		{
+			Pairs{
+				{Key: "retry", Bare: true},
+				{Key: "abcd", Value: ""},
+			},
+			`abcd="" retry`,
 		},
 	}
 
@@ -71,3 +79,254 @@ func TestPairsFormat(t *testing.T) {
 		})
 	}
 }
+
+func TestPairsFormatQuoting(t *testing.T) {
+	testCases := []struct {
+		input Pairs
+		exp   string
+	}{
+		{
+			Pairs{{Key: "msg", Value: "line1\nline2"}},
+			`msg="line1\nline2"`,
+		},
+		{
+			Pairs{{Key: "msg", Value: "a\tb"}},
+			`msg="a\tb"`,
+		},
+		{
+			Pairs{{Key: "msg", Value: "bell\x07"}},
+			`msg="bell\a"`,
+		},
+		{
+			Pairs{{Key: "msg", Value: "\xffbinary"}},
+			`msg="\xffbinary"`,
+		},
+		{
+			Pairs{{Key: "msg", Value: "sep\u2028"}},
+			`msg="sep\u2028"`,
+		},
+		{
+			Pairs{{Key: "path", Value: `C:\temp`}},
+			`path=C:\temp`,
+		},
+		{
+			Pairs{{Key: "city", Value: "Zürich"}},
+			`city=Zürich`,
+		},
+		{
+			Pairs{{Key: "my key=\"x\"\n", Value: "v"}},
+			`my_key__x__=v`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			require.Equal(t, tc.exp, tc.input.Format())
+		})
+	}
+}
+
+func TestPairsFormatRoundTrip(t *testing.T) {
+	values := []string{
+		"",
+		" ",
+		"foo",
+		"foo bar",
+		`"`,
+		`"quoted"`,
+		`a="b"`,
+		"=",
+		`\`,
+		`\"`,
+		`C:\temp\`,
+		"tab\there",
+		"new\nline",
+		"cr\r",
+		"nul\x00byte",
+		"del\x7f",
+		"\xff\xfe",
+		"caf\xe9",
+		"Zürich",
+		"\u2028",
+		"\uFFFD",
+		"emoji 🎉",
+		"can't",
+		`\'`,
+	}
+
+	pairs := make(Pairs, 0, len(values)+1)
+	for i, v := range values {
+		pairs = append(pairs, Pair{Key: "k" + strconv.Itoa(i), Value: v})
+	}
+	pairs = append(pairs, Pair{Key: "bare", Bare: true})
+
+	require.Equal(t, pairs, Split(pairs.Format()))
+
+	for _, pair := range pairs {
+		p := Pairs{pair}
+		require.Equal(t, p, Split(p.Format()))
+	}
+}
+
+func TestPairsLookup(t *testing.T) {
+	pairs := Split("status=200 path=/ status=500 retry empty=")
+
+	require.Equal(t, 0, pairs.Index("status"))
+	require.Equal(t, 3, pairs.Index("retry"))
+	require.Equal(t, -1, pairs.Index("nope"))
+
+	require.True(t, pairs.Has("retry"))
+	require.True(t, pairs.Has("empty"))
+	require.False(t, pairs.Has("nope"))
+
+	require.Equal(t, "200", pairs.Get("status"))
+	require.Equal(t, "", pairs.Get("nope"))
+
+	v, ok := pairs.Lookup("empty")
+	require.True(t, ok)
+	require.Equal(t, "", v)
+	_, ok = pairs.Lookup("nope")
+	require.False(t, ok)
+
+	require.Equal(t, []string{"200", "500"}, pairs.Values("status"))
+	require.Nil(t, pairs.Values("nope"))
+
+	require.Equal(t, map[string]string{
+		"status": "200",
+		"path":   "/",
+		"retry":  "",
+		"empty":  "",
+	}, pairs.ToMap())
+}
+
+func TestPairsMutation(t *testing.T) {
+	testCases := []struct {
+		input  string
+		mutate func(Pairs) Pairs
+		exp    string
+	}{
+		{
+			"a=1 b=2 a=3",
+			func(p Pairs) Pairs { return p.Set("a", "x") },
+			"a=x b=2",
+		},
+		{
+			"a=1 retry",
+			func(p Pairs) Pairs { return p.Set("retry", "3") },
+			"a=1 retry=3",
+		},
+		{
+			"a=1",
+			func(p Pairs) Pairs { return p.Set("b", "2") },
+			"a=1 b=2",
+		},
+		{
+			"a=1 b=2 a=3 c=4",
+			func(p Pairs) Pairs { return p.Delete("a") },
+			"b=2 c=4",
+		},
+		{
+			"a=1 b=2 a=3",
+			func(p Pairs) Pairs { p.Rename("a", "z"); return p },
+			"z=1 b=2 z=3",
+		},
+		{
+			"a=1 b=2 c=3 a=4",
+			func(p Pairs) Pairs { return p.Keep("c", "a") },
+			"a=1 c=3 a=4",
+		},
+		{
+			"a=1 b=2 c=3 a=4",
+			func(p Pairs) Pairs { return p.Drop("c", "a") },
+			"b=2",
+		},
+		{
+			"a=1 b=2",
+			func(p Pairs) Pairs { return p.Keep() },
+			"",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			res := tc.mutate(Split(tc.input))
+			require.Equal(t, tc.exp, res.Format())
+		})
+	}
+}
+
+func TestPairsFormatPreserve(t *testing.T) {
+	const line = `  time=2026-10-17T12:00:01Z level=info   msg="foo bar" path='/x'  retry esc="a\tb"`
+
+	parser := PairParser{KeepRaw: true}
+
+	pairs := parser.Split(line)
+	require.Equal(t, Pairs{
+		{Key: "time", Value: "2026-10-17T12:00:01Z", Raw: "  time=2026-10-17T12:00:01Z"},
+		{Key: "level", Value: "info", Raw: " level=info"},
+		{Key: "msg", Value: "foo bar", Raw: `   msg="foo bar"`},
+		{Key: "path", Value: "'/x'", Raw: ` path='/x'`},
+		{Key: "retry", Bare: true, Raw: "  retry"},
+		{Key: "esc", Value: "a\tb", Raw: ` esc="a\tb"`},
+	}, pairs)
+
+	testCases := []struct {
+		edit func(Pairs) Pairs
+		exp  string
+	}{
+		{
+			func(p Pairs) Pairs { return p },
+			`time=2026-10-17T12:00:01Z level=info   msg="foo bar" path='/x'  retry esc="a\tb"`,
+		},
+		{
+			func(p Pairs) Pairs { return p.Drop("level", "retry") },
+			`time=2026-10-17T12:00:01Z   msg="foo bar" path='/x' esc="a\tb"`,
+		},
+		{
+			func(p Pairs) Pairs { return p.Drop("time") },
+			`level=info   msg="foo bar" path='/x'  retry esc="a\tb"`,
+		},
+		{
+			func(p Pairs) Pairs {
+				p.Set("level", "error")
+				p.Set("msg", "foo bar")
+				p.Rename("path", "url")
+				return p
+			},
+			`time=2026-10-17T12:00:01Z level=error   msg="foo bar" url='/x'  retry esc="a\tb"`,
+		},
+		{
+			func(p Pairs) Pairs {
+				p.Set("level", `a"b`)
+				return append(Pairs{{Key: "host", Value: "web 1"}}, p...)
+			},
+			`host="web 1"  time=2026-10-17T12:00:01Z level="a\"b"   msg="foo bar" path='/x'  retry esc="a\tb"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			pairs := tc.edit(parser.Split(line))
+			require.Equal(t, tc.exp, pairs.FormatPreserve())
+		})
+	}
+}
+
+func TestPairsFormatPreserveLossy(t *testing.T) {
+	testCases := []struct {
+		input string
+		exp   string
+	}{
+		{`city="Lyon"  msg="été" id=1`, `city="Lyon"  msg="été" id=1`},
+		{`a="b"c=d`, `a="b"c=d`},
+		{`msg="hello \q world"  id=1`, `msg=""  id=1`},
+		{`to=foo"bar"   id=1`, `to=bar   id=1`},
+	}
+
+	parser := PairParser{KeepRaw: true}
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			require.Equal(t, tc.exp, parser.Split(tc.input).FormatPreserve())
+		})
+	}
+}
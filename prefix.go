@@ -0,0 +1,226 @@
+package logfmt
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Keys of the pairs produced by the prefix parsers.
+const (
+	PrefixTimestampKey = "timestamp"
+	PrefixHostKey      = "host"
+	PrefixAppKey       = "app"
+	PrefixPIDKey       = "pid"
+	PrefixStreamKey    = "stream"
+)
+
+// PrefixParser parses a header preceding the logfmt data of a line, like the ones added by syslog or container runtimes.
+type PrefixParser interface {
+	// ParsePrefix appends the pairs extracted from the header of line to pairs and returns them along with the
+	// rest of the line. If line doesn't start with the expected header, ok is false and pairs is returned unchanged.
+	ParsePrefix(line string, pairs Pairs) (res Pairs, rest string, ok bool)
+}
+
+var (
+	// SyslogPrefix parses a BSD syslog header (RFC 3164) like:
+	//
+	//	<34>Oct 17 12:00:01 host app[123]: level=info
+	//
+	// The priority is optional and the timestamp can also be in the RFC 3339 format.
+	// It produces the timestamp, host, app and pid pairs, pid only if present.
+	SyslogPrefix PrefixParser = syslogPrefix{}
+
+	// CRIPrefix parses the header of a line written by a Kubernetes container runtime like:
+	//
+	//	2026-10-17T12:00:01.123456789Z stdout F level=info
+	//
+	// It produces the timestamp and stream pairs. Partial lines, tagged P, are parsed like full lines.
+	CRIPrefix PrefixParser = criPrefix{}
+
+	// DockerPrefix parses the timestamp added by docker logs --timestamps like:
+	//
+	//	2026-10-17T12:00:01.123456789Z level=info
+	//
+	// It produces the timestamp pair.
+	DockerPrefix PrefixParser = dockerPrefix{}
+
+	// AutoPrefix tries CRIPrefix, SyslogPrefix and DockerPrefix in order and uses the first one which matches.
+	AutoPrefix PrefixParser = MultiPrefix(CRIPrefix, SyslogPrefix, DockerPrefix)
+)
+
+var prefixParsers = map[string]PrefixParser{
+	"syslog": SyslogPrefix,
+	"cri":    CRIPrefix,
+	"docker": DockerPrefix,
+	"auto":   AutoPrefix,
+}
+
+// ParsePrefixParser returns the prefix parser with the given name: "syslog", "cri", "docker" or "auto".
+// The name "none" returns a nil parser.
+func ParsePrefixParser(name string) (PrefixParser, error) {
+	if name == "none" {
+		return nil, nil
+	}
+	if p, ok := prefixParsers[name]; ok {
+		return p, nil
+	}
+	return nil, fmt.Errorf("logfmt: invalid prefix parser %q", name)
+}
+
+type multiPrefix []PrefixParser
+
+// MultiPrefix returns a PrefixParser which tries each parser in order and uses the first one which matches.
+func MultiPrefix(parsers ...PrefixParser) PrefixParser {
+	return multiPrefix(parsers)
+}
+
+func (m multiPrefix) ParsePrefix(line string, pairs Pairs) (Pairs, string, bool) {
+	for _, p := range m {
+		if res, rest, ok := p.ParsePrefix(line, pairs); ok {
+			return res, rest, true
+		}
+	}
+	return pairs, line, false
+}
+
+type syslogPrefix struct{}
+
+func (syslogPrefix) ParsePrefix(line string, pairs Pairs) (Pairs, string, bool) {
+	s := line
+
+	// Priority
+	if strings.HasPrefix(s, "<") {
+		end := strings.IndexByte(s, '>')
+		if end < 2 || !isDigits(s[1:end]) {
+			return pairs, line, false
+		}
+		s = s[end+1:]
+	}
+
+	// Timestamp, either "Oct 17 12:00:01" or RFC 3339
+	var timestamp string
+	if len(s) >= len(time.Stamp) && s[0] >= 'A' && s[0] <= 'Z' {
+		timestamp = s[:len(time.Stamp)]
+		if _, err := time.Parse(time.Stamp, timestamp); err != nil {
+			return pairs, line, false
+		}
+		s = s[len(time.Stamp):]
+	} else {
+		var ok bool
+		if timestamp, s, ok = cutTimestamp(s); !ok {
+			return pairs, line, false
+		}
+	}
+
+	// Host
+	host, s, ok := cutField(s)
+	if !ok || strings.ContainsRune(host, '=') {
+		return pairs, line, false
+	}
+
+	// Tag, "app[123]:" or "app:"
+	tag, rest, ok := cutField(s)
+	if !ok && s != "" && !strings.ContainsRune(s, ' ') {
+		tag, rest, ok = s, "", true
+	}
+	if !ok || !strings.HasSuffix(tag, ":") || strings.ContainsRune(tag, '=') {
+		return pairs, line, false
+	}
+	tag = tag[:len(tag)-1]
+
+	var app, pid string
+	if pos := strings.IndexByte(tag, '['); pos > 0 && strings.HasSuffix(tag, "]") {
+		app, pid = tag[:pos], tag[pos+1:len(tag)-1]
+	} else {
+		app = tag
+	}
+	if app == "" {
+		return pairs, line, false
+	}
+
+	pairs = append(pairs,
+		Pair{Key: PrefixTimestampKey, Value: timestamp},
+		Pair{Key: PrefixHostKey, Value: host},
+		Pair{Key: PrefixAppKey, Value: app},
+	)
+	if pid != "" {
+		pairs = append(pairs, Pair{Key: PrefixPIDKey, Value: pid})
+	}
+
+	return pairs, rest, true
+}
+
+type criPrefix struct{}
+
+func (criPrefix) ParsePrefix(line string, pairs Pairs) (Pairs, string, bool) {
+	timestamp, s, ok := cutTimestamp(line)
+	if !ok {
+		return pairs, line, false
+	}
+
+	stream, s, ok := cutField(s)
+	if !ok || (stream != "stdout" && stream != "stderr") {
+		return pairs, line, false
+	}
+
+	tag, rest, ok := cutField(s)
+	if !ok && (s == "F" || s == "P") {
+		tag, rest, ok = s, "", true
+	}
+	if !ok || (tag != "F" && tag != "P") {
+		return pairs, line, false
+	}
+
+	pairs = append(pairs,
+		Pair{Key: PrefixTimestampKey, Value: timestamp},
+		Pair{Key: PrefixStreamKey, Value: stream},
+	)
+
+	return pairs, rest, true
+}
+
+type dockerPrefix struct{}
+
+func (dockerPrefix) ParsePrefix(line string, pairs Pairs) (Pairs, string, bool) {
+	timestamp, rest, ok := cutTimestamp(line)
+	if !ok {
+		return pairs, line, false
+	}
+
+	return append(pairs, Pair{Key: PrefixTimestampKey, Value: timestamp}), rest, true
+}
+
+// cutTimestamp cuts the RFC 3339 timestamp at the start of s, followed by spaces or the end of s.
+func cutTimestamp(s string) (timestamp, rest string, ok bool) {
+	timestamp, rest, ok = cutField(s)
+	if !ok {
+		timestamp, rest = s, ""
+	}
+	if _, err := time.Parse(time.RFC3339Nano, timestamp); err != nil {
+		return "", s, false
+	}
+	return timestamp, rest, true
+}
+
+// cutField cuts the non-empty field at the start of s, after optional spaces.
+// It returns false if the field is not followed by a space.
+func cutField(s string) (field, rest string, ok bool) {
+	s = strings.TrimLeft(s, " ")
+
+	pos := strings.IndexByte(s, ' ')
+	if pos <= 0 {
+		return "", s, false
+	}
+
+	return s[:pos], strings.TrimLeft(s[pos:], " "), true
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
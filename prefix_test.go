@@ -0,0 +1,161 @@
+package logfmt
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrefixParsers(t *testing.T) {
+	testCases := []struct {
+		parser PrefixParser
+		input  string
+		exp    Pairs
+		rest   string
+		ok     bool
+	}{
+		{
+			SyslogPrefix,
+			`Oct 17 12:00:01 host app[123]: level=info msg="foo bar"`,
+			Pairs{
+				{Key: "timestamp", Value: "Oct 17 12:00:01"},
+				{Key: "host", Value: "host"},
+				{Key: "app", Value: "app"},
+				{Key: "pid", Value: "123"},
+			},
+			`level=info msg="foo bar"`,
+			true,
+		},
+		{
+			SyslogPrefix,
+			`<34>Oct  7 02:00:01 host cron: level=info`,
+			Pairs{
+				{Key: "timestamp", Value: "Oct  7 02:00:01"},
+				{Key: "host", Value: "host"},
+				{Key: "app", Value: "cron"},
+			},
+			`level=info`,
+			true,
+		},
+		{
+			SyslogPrefix,
+			`2026-10-17T12:00:01.123+02:00 host app[1]:`,
+			Pairs{
+				{Key: "timestamp", Value: "2026-10-17T12:00:01.123+02:00"},
+				{Key: "host", Value: "host"},
+				{Key: "app", Value: "app"},
+				{Key: "pid", Value: "1"},
+			},
+			``,
+			true,
+		},
+		{SyslogPrefix, `level=info msg=foo`, nil, `level=info msg=foo`, false},
+		{SyslogPrefix, `Oct 17 12:00:01 host level=info`, nil, `Oct 17 12:00:01 host level=info`, false},
+		{SyslogPrefix, `<a>Oct 17 12:00:01 host app: a=b`, nil, `<a>Oct 17 12:00:01 host app: a=b`, false},
+		{
+			CRIPrefix,
+			`2026-10-17T12:00:01.123456789Z stdout F level=info`,
+			Pairs{
+				{Key: "timestamp", Value: "2026-10-17T12:00:01.123456789Z"},
+				{Key: "stream", Value: "stdout"},
+			},
+			`level=info`,
+			true,
+		},
+		{
+			CRIPrefix,
+			`2026-10-17T12:00:01Z stderr P`,
+			Pairs{
+				{Key: "timestamp", Value: "2026-10-17T12:00:01Z"},
+				{Key: "stream", Value: "stderr"},
+			},
+			``,
+			true,
+		},
+		{CRIPrefix, `2026-10-17T12:00:01Z level=info`, nil, `2026-10-17T12:00:01Z level=info`, false},
+		{
+			DockerPrefix,
+			`2026-10-17T12:00:01.5Z level=info`,
+			Pairs{{Key: "timestamp", Value: "2026-10-17T12:00:01.5Z"}},
+			`level=info`,
+			true,
+		},
+		{DockerPrefix, `time=2026-10-17T12:00:01.5Z level=info`, nil, `time=2026-10-17T12:00:01.5Z level=info`, false},
+		{
+			AutoPrefix,
+			`2026-10-17T12:00:01Z stdout F a=b`,
+			Pairs{
+				{Key: "timestamp", Value: "2026-10-17T12:00:01Z"},
+				{Key: "stream", Value: "stdout"},
+			},
+			`a=b`,
+			true,
+		},
+		{
+			AutoPrefix,
+			`2026-10-17T12:00:01Z host app: a=b`,
+			Pairs{
+				{Key: "timestamp", Value: "2026-10-17T12:00:01Z"},
+				{Key: "host", Value: "host"},
+				{Key: "app", Value: "app"},
+			},
+			`a=b`,
+			true,
+		},
+		{
+			AutoPrefix,
+			`2026-10-17T12:00:01Z a=b c=d`,
+			Pairs{{Key: "timestamp", Value: "2026-10-17T12:00:01Z"}},
+			`a=b c=d`,
+			true,
+		},
+		{AutoPrefix, `a=b`, nil, `a=b`, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			pairs, rest, ok := tc.parser.ParsePrefix(tc.input, nil)
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.exp, pairs)
+			require.Equal(t, tc.rest, rest)
+		})
+	}
+}
+
+func TestParsePrefixParser(t *testing.T) {
+	p, err := ParsePrefixParser("cri")
+	require.NoError(t, err)
+	require.Equal(t, CRIPrefix, p)
+
+	p, err = ParsePrefixParser("none")
+	require.NoError(t, err)
+	require.Nil(t, p)
+
+	_, err = ParsePrefixParser("foobar")
+	require.Error(t, err)
+}
+
+func TestDecoderPrefix(t *testing.T) {
+	const input = "Oct 17 12:00:01 host app[123]: level=info\nlevel=debug\nOct 17 12:00:02 host app[123]: a=1 =b\n"
+
+	dec := NewDecoder(strings.NewReader(input))
+	dec.Prefix = SyslogPrefix
+	dec.Parser.Strict = true
+
+	require.True(t, dec.Next())
+	require.Equal(t, Pairs{
+		{Key: "timestamp", Value: "Oct 17 12:00:01"},
+		{Key: "host", Value: "host"},
+		{Key: "app", Value: "app"},
+		{Key: "pid", Value: "123"},
+		{Key: "level", Value: "info"},
+	}, dec.Pairs())
+	require.Equal(t, "Oct 17 12:00:01 host app[123]: level=info", dec.Text())
+
+	require.True(t, dec.Next())
+	require.Equal(t, Pairs{{Key: "level", Value: "debug"}}, dec.Pairs())
+
+	require.False(t, dec.Next())
+	require.Equal(t, &SyntaxError{Line: 3, Offset: 35, Reason: reasonEmptyKey}, dec.Err())
+}
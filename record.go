@@ -0,0 +1,212 @@
+package logfmt
+
+import (
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SlogRecordOptions are options for ToSlogRecord. A zero SlogRecordOptions consists entirely of default values.
+type SlogRecordOptions struct {
+	// TimeKey, LevelKey and MessageKey are the keys of the pairs holding the time, level and message of the record.
+	// They default to slog.TimeKey, slog.LevelKey and slog.MessageKey.
+	TimeKey    string
+	LevelKey   string
+	MessageKey string
+
+	// TimeLayouts are the layouts tried to parse the time of the record and time values.
+	// They default to DefaultTimeLayouts.
+	TimeLayouts []string
+
+	// GroupSeparator separates the group names in a key. It defaults to ".".
+	GroupSeparator string
+}
+
+func (o *SlogRecordOptions) withDefaults() SlogRecordOptions {
+	var res SlogRecordOptions
+	if o != nil {
+		res = *o
+	}
+
+	if res.TimeKey == "" {
+		res.TimeKey = slog.TimeKey
+	}
+	if res.LevelKey == "" {
+		res.LevelKey = slog.LevelKey
+	}
+	if res.MessageKey == "" {
+		res.MessageKey = slog.MessageKey
+	}
+	if len(res.TimeLayouts) == 0 {
+		res.TimeLayouts = DefaultTimeLayouts
+	}
+	if res.GroupSeparator == "" {
+		res.GroupSeparator = "."
+	}
+
+	return res
+}
+
+// ToSlogRecord converts the pairs into a slog.Record which can be passed to any slog.Handler.
+//
+// The first pairs with the time, level and message keys are used as the time, level and message of the record,
+// as long as their value can be parsed; otherwise they are kept as attributes.
+//
+// Every other pair becomes an attribute whose kind is inferred from the value: booleans, integers, floats,
+// durations and times are recognized, anything else is a string. A bare key is a true boolean.
+// Keys containing the group separator are rebuilt into groups, so http.req.method=GET becomes
+// the attribute "method" in the group "req" in the group "http".
+func ToSlogRecord(pairs Pairs, opts *SlogRecordOptions) slog.Record {
+	o := opts.withDefaults()
+
+	var (
+		t       time.Time
+		level   slog.Level
+		message string
+
+		hasTime, hasLevel, hasMessage bool
+	)
+
+	root := new(attrNode)
+
+	for _, pair := range pairs {
+		switch {
+		case !hasTime && pair.Key == o.TimeKey:
+			if tm, err := pair.Time(o.TimeLayouts...); err == nil {
+				t, hasTime = tm, true
+				continue
+			}
+		case !hasLevel && pair.Key == o.LevelKey:
+			if l, ok := parseLevel(pair.Value); ok {
+				level, hasLevel = l, true
+				continue
+			}
+		case !hasMessage && pair.Key == o.MessageKey:
+			message, hasMessage = pair.Value, true
+			continue
+		}
+
+		root.add(pair.Key, o.GroupSeparator, inferValue(pair, o.TimeLayouts))
+	}
+
+	r := slog.NewRecord(t, level, message, 0)
+	r.AddAttrs(root.attrs()...)
+
+	return r
+}
+
+var levelAliases = map[string]slog.Level{
+	"trace":    slog.LevelDebug - 4,
+	"warning":  slog.LevelWarn,
+	"err":      slog.LevelError,
+	"critical": slog.LevelError + 4,
+	"crit":     slog.LevelError + 4,
+	"fatal":    slog.LevelError + 4,
+	"panic":    slog.LevelError + 4,
+}
+
+func parseLevel(s string) (slog.Level, bool) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(s)); err == nil {
+		return level, true
+	}
+
+	level, ok := levelAliases[strings.ToLower(s)]
+	return level, ok
+}
+
+// inferValue returns the value of the pair with the most specific kind it can be parsed as.
+func inferValue(pair Pair, timeLayouts []string) slog.Value {
+	s := pair.Value
+
+	switch {
+	case pair.Bare:
+		return slog.BoolValue(true)
+	case s == "true" || s == "false":
+		return slog.BoolValue(s == "true")
+	case !looksNumeric(s):
+		if t, err := pair.Time(timeLayouts...); err == nil {
+			return slog.TimeValue(t)
+		}
+		return slog.StringValue(s)
+	}
+
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return slog.Int64Value(n)
+	}
+	if n, err := strconv.ParseUint(s, 10, 64); err == nil {
+		return slog.Uint64Value(n)
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return slog.Float64Value(f)
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return slog.DurationValue(d)
+	}
+	if t, err := pair.Time(timeLayouts...); err == nil {
+		return slog.TimeValue(t)
+	}
+
+	return slog.StringValue(s)
+}
+
+// looksNumeric returns true if s starts like a number, duration or timestamp.
+// It avoids treating words like "Inf" or "NaN" as numbers.
+func looksNumeric(s string) bool {
+	s = strings.TrimLeft(s, "+-")
+	if s != "" && s[0] == '.' {
+		s = s[1:]
+	}
+	return s != "" && s[0] >= '0' && s[0] <= '9'
+}
+
+// attrNode is a node in a tree of attributes rebuilt from dotted keys.
+// A node is either a leaf with a value or a group with children.
+type attrNode struct {
+	key      string
+	value    slog.Value
+	group    bool
+	children []*attrNode
+}
+
+func (n *attrNode) add(key, sep string, value slog.Value) {
+	path := strings.Split(key, sep)
+	for _, p := range path {
+		if p == "" {
+			// Can't rebuild groups from a key like "a..b", keep it as is.
+			n.children = append(n.children, &attrNode{key: key, value: value})
+			return
+		}
+	}
+
+	cur := n
+	for _, name := range path[:len(path)-1] {
+		var next *attrNode
+		for _, child := range cur.children {
+			if child.group && child.key == name {
+				next = child
+				break
+			}
+		}
+		if next == nil {
+			next = &attrNode{key: name, group: true}
+			cur.children = append(cur.children, next)
+		}
+		cur = next
+	}
+
+	cur.children = append(cur.children, &attrNode{key: path[len(path)-1], value: value})
+}
+
+func (n *attrNode) attrs() []slog.Attr {
+	res := make([]slog.Attr, 0, len(n.children))
+	for _, child := range n.children {
+		if child.group {
+			res = append(res, slog.Attr{Key: child.key, Value: slog.GroupValue(child.attrs()...)})
+		} else {
+			res = append(res, slog.Attr{Key: child.key, Value: child.value})
+		}
+	}
+	return res
+}
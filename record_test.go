@@ -0,0 +1,91 @@
+package logfmt
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestToSlogRecord(t *testing.T) {
+	pairs := Split(`time=2026-10-17T12:00:01Z level=warning msg="disk almost full" host=web-1 used=0.93 free=1024 big=18446744073709551615 ok=false elapsed=1.5s since=2026-10-17T11:00:00Z dry-run http.req.method=GET http.req.path=/x http.status=200 version=1.2.3 name=NaN`)
+
+	r := ToSlogRecord(pairs, nil)
+
+	require.Equal(t, time.Date(2026, 10, 17, 12, 0, 1, 0, time.UTC), r.Time)
+	require.Equal(t, slog.LevelWarn, r.Level)
+	require.Equal(t, "disk almost full", r.Message)
+
+	var attrs []slog.Attr
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+
+	exp := []slog.Attr{
+		slog.String("host", "web-1"),
+		slog.Float64("used", 0.93),
+		slog.Int64("free", 1024),
+		slog.Uint64("big", 18446744073709551615),
+		slog.Bool("ok", false),
+		slog.Duration("elapsed", 1500*time.Millisecond),
+		slog.Time("since", time.Date(2026, 10, 17, 11, 0, 0, 0, time.UTC)),
+		slog.Bool("dry-run", true),
+		slog.Group("http",
+			slog.Group("req", slog.String("method", "GET"), slog.String("path", "/x")),
+			slog.Int64("status", 200),
+		),
+		slog.String("version", "1.2.3"),
+		slog.String("name", "NaN"),
+	}
+
+	require.Equal(t, len(exp), len(attrs))
+	for i := range exp {
+		require.True(t, exp[i].Equal(attrs[i]), "expected %v, got %v", exp[i], attrs[i])
+	}
+}
+
+func TestToSlogRecordOptions(t *testing.T) {
+	pairs := Split(`ts=17/10/2026 severity=FATAL message=boom time=not-a-time level=nope a/b=1`)
+
+	r := ToSlogRecord(pairs, &SlogRecordOptions{
+		TimeKey:        "ts",
+		LevelKey:       "severity",
+		MessageKey:     "message",
+		TimeLayouts:    []string{"02/01/2006"},
+		GroupSeparator: "/",
+	})
+
+	require.Equal(t, time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC), r.Time)
+	require.Equal(t, slog.LevelError+4, r.Level)
+	require.Equal(t, "boom", r.Message)
+
+	var attrs []slog.Attr
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+
+	exp := []slog.Attr{
+		slog.String("time", "not-a-time"),
+		slog.String("level", "nope"),
+		slog.Group("a", slog.Int64("b", 1)),
+	}
+	require.Equal(t, len(exp), len(attrs))
+	for i := range exp {
+		require.True(t, exp[i].Equal(attrs[i]), "expected %v, got %v", exp[i], attrs[i])
+	}
+}
+
+func TestToSlogRecordReplay(t *testing.T) {
+	const line = `time=2026-10-17T12:00:01.5Z level=ERROR msg="request failed" service=api http.method=GET http.status=500 http.elapsed=1.5s retry=true`
+
+	var buf bytes.Buffer
+
+	h := NewHandler(&buf, nil)
+	require.NoError(t, h.Handle(context.Background(), ToSlogRecord(Split(line), nil)))
+	require.Equal(t, line+"\n", buf.String())
+}
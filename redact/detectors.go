@@ -0,0 +1,133 @@
+package redact
+
+import (
+	"net"
+	"regexp"
+	"sort"
+)
+
+// Detector finds sensitive data of a specific kind in a value.
+type Detector struct {
+	// Name identifies the detector in rules, like "email".
+	Name string
+	// Pattern matches the candidates.
+	Pattern *regexp.Regexp
+	// Validate, if set, filters out the candidates which are false positives.
+	Validate func(s string) bool
+}
+
+// FindAll returns the positions of the sensitive data in s, like regexp.Regexp.FindAllStringIndex.
+func (d *Detector) FindAll(s string) [][]int {
+	matches := d.Pattern.FindAllStringIndex(s, -1)
+	if d.Validate == nil {
+		return matches
+	}
+
+	res := matches[:0]
+	for _, m := range matches {
+		if d.Validate(s[m[0]:m[1]]) {
+			res = append(res, m)
+		}
+	}
+	return res
+}
+
+var (
+	// Email detects email addresses.
+	Email = &Detector{
+		Name:    "email",
+		Pattern: regexp.MustCompile(`[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}`),
+	}
+
+	// CreditCard detects payment card numbers of 13 to 19 digits, optionally separated by spaces or dashes,
+	// whose check digit is valid.
+	CreditCard = &Detector{
+		Name:     "card",
+		Pattern:  regexp.MustCompile(`\b\d(?:[ \-]?\d){12,18}\b`),
+		Validate: luhn,
+	}
+
+	// IPv4 detects IPv4 addresses.
+	IPv4 = &Detector{
+		Name:    "ipv4",
+		Pattern: regexp.MustCompile(`\b\d{1,3}(?:\.\d{1,3}){3}\b`),
+		Validate: func(s string) bool {
+			return net.ParseIP(s) != nil
+		},
+	}
+
+	// IPv6 detects IPv6 addresses.
+	IPv6 = &Detector{
+		Name:    "ipv6",
+		Pattern: regexp.MustCompile(`(?i)(?:[0-9a-f]{0,4}:){2,7}[0-9a-f]{0,4}`),
+		Validate: func(s string) bool {
+			ip := net.ParseIP(s)
+			return ip != nil && ip.To4() == nil
+		},
+	}
+
+	// JWT detects JSON Web Tokens.
+	JWT = &Detector{
+		Name:    "jwt",
+		Pattern: regexp.MustCompile(`\beyJ[a-zA-Z0-9_\-]+\.eyJ[a-zA-Z0-9_\-]+\.[a-zA-Z0-9_\-]*`),
+	}
+
+	// BearerToken detects the credentials of HTTP authorization headers like "Bearer abc123".
+	BearerToken = &Detector{
+		Name:    "bearer",
+		Pattern: regexp.MustCompile(`(?i)\b(?:bearer|basic|token)\s+[a-zA-Z0-9._~+/\-]+=*`),
+	}
+)
+
+var detectors = map[string]*Detector{
+	Email.Name:       Email,
+	CreditCard.Name:  CreditCard,
+	IPv4.Name:        IPv4,
+	IPv6.Name:        IPv6,
+	JWT.Name:         JWT,
+	BearerToken.Name: BearerToken,
+}
+
+// LookupDetector returns the built-in detector with the given name.
+func LookupDetector(name string) (*Detector, bool) {
+	d, ok := detectors[name]
+	return d, ok
+}
+
+// DetectorNames returns the names of the built-in detectors, sorted.
+func DetectorNames() []string {
+	names := make([]string, 0, len(detectors))
+	for name := range detectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// luhn returns true if the digits of s have a valid Luhn check digit. Spaces and dashes are ignored.
+func luhn(s string) bool {
+	var (
+		sum    int
+		digits int
+		double bool
+	)
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c == ' ' || c == '-' {
+			continue
+		}
+
+		n := int(c - '0')
+		if double {
+			n *= 2
+			if n > 9 {
+				n -= 9
+			}
+		}
+		sum += n
+		digits++
+		double = !double
+	}
+
+	return digits >= 13 && sum%10 == 0
+}
@@ -0,0 +1,361 @@
+// Package redact removes secrets and personal data from logfmt pairs.
+//
+// A Redactor applies a list of rules to the pairs of a line. A rule selects pairs by key and by value and says what
+// to do with them: mask the data, replace it with a keyed hash or drop the pair.
+package redact
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/vrischmann/logfmt"
+)
+
+// Action is what a rule does with the data it selects.
+type Action int
+
+const (
+	// Mask replaces the data with Redactor.Mask.
+	Mask Action = iota
+	// Hash replaces the data with a keyed hash of it, so the same data always gives the same pseudonym
+	// but can't be recovered without the key.
+	Hash
+	// Drop removes the whole pair.
+	Drop
+)
+
+var actionNames = []string{
+	Mask: "mask",
+	Hash: "hash",
+	Drop: "drop",
+}
+
+// ParseAction parses the name of an action: "mask", "hash" or "drop".
+func ParseAction(s string) (Action, error) {
+	for i, name := range actionNames {
+		if name == s {
+			return Action(i), nil
+		}
+	}
+	return Mask, fmt.Errorf("redact: invalid action %q", s)
+}
+
+func (a Action) String() string {
+	if a < 0 || int(a) >= len(actionNames) {
+		return fmt.Sprintf("Action(%d)", int(a))
+	}
+	return actionNames[a]
+}
+
+// Rule selects the data to redact and the action to apply.
+//
+// A pair is selected if its key matches Key and its value matches Value or Detector.
+// If neither Value nor Detector is set the whole value is redacted, otherwise only the parts they match are.
+type Rule struct {
+	// Key is a glob matched against the key, ignoring case, with the syntax of path.Match.
+	// An empty Key matches every key.
+	Key string
+	// Value, if set, matches the data to redact in the value.
+	Value *regexp.Regexp
+	// Detector, if set, finds the data to redact in the value.
+	Detector *Detector
+
+	Action Action
+}
+
+// ParseRule parses a rule written as "action:condition,condition...", for example:
+//
+//	mask:key=password
+//	hash:key=user*
+//	drop:key=*token*
+//	mask:detect=email
+//	hash:key=msg,value=[0-9]{6}
+//
+// A condition is key=GLOB, detect=NAME with the name of a built-in detector or value=REGEXP.
+// Since a regexp can contain commas, value must be the last condition.
+func ParseRule(s string) (Rule, error) {
+	var rule Rule
+
+	pos := strings.IndexByte(s, ':')
+	if pos == -1 {
+		return rule, fmt.Errorf("redact: invalid rule %q, no action", s)
+	}
+
+	action, err := ParseAction(s[:pos])
+	if err != nil {
+		return rule, err
+	}
+	rule.Action = action
+
+	conditions := s[pos+1:]
+	for conditions != "" {
+		var cond string
+		if strings.HasPrefix(conditions, "value=") {
+			cond, conditions = conditions, ""
+		} else if pos := strings.IndexByte(conditions, ','); pos >= 0 {
+			cond, conditions = conditions[:pos], conditions[pos+1:]
+		} else {
+			cond, conditions = conditions, ""
+		}
+
+		name, arg, ok := strings.Cut(cond, "=")
+		if !ok {
+			return rule, fmt.Errorf("redact: invalid condition %q in rule %q", cond, s)
+		}
+
+		switch name {
+		case "key":
+			if _, err := path.Match(arg, ""); err != nil {
+				return rule, fmt.Errorf("redact: invalid key glob %q: %w", arg, err)
+			}
+			rule.Key = arg
+
+		case "value":
+			re, err := regexp.Compile(arg)
+			if err != nil {
+				return rule, fmt.Errorf("redact: invalid value regexp %q: %w", arg, err)
+			}
+			rule.Value = re
+
+		case "detect":
+			d, ok := LookupDetector(arg)
+			if !ok {
+				return rule, fmt.Errorf("redact: unknown detector %q, must be one of %s", arg, strings.Join(DetectorNames(), ", "))
+			}
+			rule.Detector = d
+
+		default:
+			return rule, fmt.Errorf("redact: invalid condition %q in rule %q", cond, s)
+		}
+	}
+
+	if rule.Key == "" && rule.Value == nil && rule.Detector == nil {
+		return rule, fmt.Errorf("redact: rule %q has no condition", s)
+	}
+
+	return rule, nil
+}
+
+// DefaultMask is the text replacing masked data unless changed with Redactor.Mask.
+const DefaultMask = "[REDACTED]"
+
+// Redactor applies rules to the pairs of log lines.
+type Redactor struct {
+	// Rules are applied in order, each one to the result of the previous ones.
+	Rules []Rule
+	// Mask is the text replacing the data redacted by the Mask action. It defaults to DefaultMask.
+	Mask string
+	// HashKey is the key of the HMAC-SHA256 used by the Hash action. Without a secret key the pseudonyms of guessable
+	// data, like IP addresses, can be reversed by brute force.
+	HashKey []byte
+}
+
+// DefaultRules returns rules masking the values of keys which usually hold credentials as well as the data found
+// by every built-in detector.
+func DefaultRules() []Rule {
+	rules := []Rule{
+		{Key: "*password*", Action: Mask},
+		{Key: "*passwd*", Action: Mask},
+		{Key: "*secret*", Action: Mask},
+		{Key: "*token*", Action: Mask},
+		{Key: "*api_key*", Action: Mask},
+		{Key: "*apikey*", Action: Mask},
+		{Key: "authorization", Action: Mask},
+		{Key: "cookie", Action: Mask},
+	}
+	for _, name := range DetectorNames() {
+		d, _ := LookupDetector(name)
+		rules = append(rules, Rule{Detector: d, Action: Mask})
+	}
+	return rules
+}
+
+// Redact applies the rules to the pairs. The pairs are modified in place and the result is returned
+// since dropping pairs shortens the slice.
+//
+// Rules without a key condition look for data in the keys as well as in the values. Consecutive bare keys, like the
+// words of a message which is not logfmt, are searched as a single text: "logged in from 10.1.2.3" is found even if each
+// word is a bare key.
+//
+// The Raw text of a redacted pair is cleared so that Pairs.AppendFormatPreserve can't write the original data back.
+func (r *Redactor) Redact(pairs logfmt.Pairs) logfmt.Pairs {
+	res := pairs[:0]
+	inPlace := true
+
+	for i := 0; i < len(pairs); {
+		if !pairs[i].Bare {
+			if pair, ok := r.redactPair(pairs[i]); ok {
+				res = append(res, pair)
+			}
+			i++
+			continue
+		}
+
+		end := i + 1
+		for end < len(pairs) && pairs[end].Bare {
+			end++
+		}
+
+		words, changed := r.redactText(pairs[i:end])
+		if !changed {
+			res = append(res, pairs[i:end]...)
+			i = end
+			continue
+		}
+
+		if inPlace && len(res)+len(words) > end {
+			// More words than before: don't overwrite the pairs not read yet
+			res = append(make(logfmt.Pairs, 0, len(pairs)+len(words)), res...)
+			inPlace = false
+		}
+		for _, word := range words {
+			res = append(res, logfmt.Pair{Key: word, Bare: true})
+		}
+		i = end
+	}
+
+	return res
+}
+
+// redactPair applies the rules to a pair which is not bare. It returns false if the pair is dropped.
+func (r *Redactor) redactPair(pair logfmt.Pair) (logfmt.Pair, bool) {
+	for i := range r.Rules {
+		rule := &r.Rules[i]
+
+		if rule.Key == "" {
+			if matches := rule.findAll(pair.Key); len(matches) > 0 {
+				if rule.Action == Drop {
+					return pair, false
+				}
+				pair.Key = r.replaceMatches(rule.Action, pair.Key, matches)
+				pair.Raw = ""
+			}
+		}
+
+		if !rule.matchKey(pair.Key) {
+			continue
+		}
+
+		if rule.Value == nil && rule.Detector == nil {
+			if rule.Action == Drop {
+				return pair, false
+			}
+			pair.Value = r.replace(rule.Action, pair.Value)
+			pair.Raw = ""
+			continue
+		}
+
+		matches := rule.findAll(pair.Value)
+		if len(matches) == 0 {
+			continue
+		}
+		if rule.Action == Drop {
+			return pair, false
+		}
+		pair.Value = r.replaceMatches(rule.Action, pair.Value, matches)
+		pair.Raw = ""
+	}
+
+	return pair, true
+}
+
+// redactText applies the rules without a key condition to the text made of the keys of consecutive bare pairs.
+// It returns the words of the redacted text and true if it changed. The Drop action only removes the data found.
+func (r *Redactor) redactText(pairs logfmt.Pairs) ([]string, bool) {
+	// A bare key has no value to redact, it can only be dropped by its key
+	words := make([]string, 0, len(pairs))
+	for _, pair := range pairs {
+		if !r.dropsKey(pair.Key) {
+			words = append(words, pair.Key)
+		}
+	}
+	text := strings.Join(words, " ")
+
+	changed := len(words) < len(pairs)
+	for i := range r.Rules {
+		rule := &r.Rules[i]
+		if rule.Key != "" || (rule.Value == nil && rule.Detector == nil) {
+			continue
+		}
+
+		matches := rule.findAll(text)
+		if len(matches) == 0 {
+			continue
+		}
+		text = r.replaceMatches(rule.Action, text, matches)
+		changed = true
+	}
+	if !changed {
+		return nil, false
+	}
+
+	return strings.Fields(text), true
+}
+
+// dropsKey returns true if a rule drops the pairs with the key whatever their value.
+func (r *Redactor) dropsKey(key string) bool {
+	for i := range r.Rules {
+		rule := &r.Rules[i]
+		if rule.Action == Drop && rule.Key != "" && rule.Value == nil && rule.Detector == nil && rule.matchKey(key) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) matchKey(key string) bool {
+	if r.Key == "" {
+		return true
+	}
+	ok, _ := path.Match(strings.ToLower(r.Key), strings.ToLower(key))
+	return ok
+}
+
+func (r *Rule) findAll(value string) [][]int {
+	if r.Detector != nil {
+		return r.Detector.FindAll(value)
+	}
+	return r.Value.FindAllStringIndex(value, -1)
+}
+
+func (r *Redactor) replaceMatches(action Action, value string, matches [][]int) string {
+	var (
+		sb   strings.Builder
+		prev int
+	)
+	for _, m := range matches {
+		sb.WriteString(value[prev:m[0]])
+		sb.WriteString(r.replace(action, value[m[0]:m[1]]))
+		prev = m[1]
+	}
+	sb.WriteString(value[prev:])
+
+	return sb.String()
+}
+
+func (r *Redactor) replace(action Action, s string) string {
+	switch action {
+	case Drop:
+		return ""
+	case Hash:
+		return r.hash(s)
+	}
+	if r.Mask == "" {
+		return DefaultMask
+	}
+	return r.Mask
+}
+
+// hash returns the pseudonym of s: the first 8 bytes of its HMAC in hexadecimal, prefixed by "h:".
+func (r *Redactor) hash(s string) string {
+	mac := hmac.New(sha256.New, r.HashKey)
+	mac.Write([]byte(s))
+	sum := mac.Sum(nil)
+
+	return "h:" + hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,196 @@
+package redact
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vrischmann/logfmt"
+)
+
+func TestParseRule(t *testing.T) {
+	testCases := []struct {
+		input string
+		exp   Rule
+	}{
+		{"mask:key=password", Rule{Key: "password", Action: Mask}},
+		{"drop:key=*token*", Rule{Key: "*token*", Action: Drop}},
+		{"hash:detect=email", Rule{Detector: Email, Action: Hash}},
+		{"hash:key=msg,detect=ipv4", Rule{Key: "msg", Detector: IPv4, Action: Hash}},
+		{"mask:key=id,value=[0-9]{2,3}", Rule{Key: "id", Value: regexp.MustCompile("[0-9]{2,3}"), Action: Mask}},
+	}
+
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			rule, err := ParseRule(tc.input)
+			require.NoError(t, err)
+			require.Equal(t, tc.exp, rule)
+		})
+	}
+
+	for _, input := range []string{
+		"password",
+		"erase:key=password",
+		"mask:",
+		"mask:key",
+		"mask:key=[",
+		"mask:detect=foobar",
+		"mask:value=(",
+		"mask:name=foo",
+	} {
+		_, err := ParseRule(input)
+		require.Error(t, err, input)
+	}
+}
+
+func TestDetectors(t *testing.T) {
+	testCases := []struct {
+		detector *Detector
+		input    string
+		exp      []string
+	}{
+		{Email, "from vincent@example.com to foo.bar+baz@mail.example.org", []string{"vincent@example.com", "foo.bar+baz@mail.example.org"}},
+		{Email, "user@localhost", nil},
+		{CreditCard, "card 4111 1111 1111 1111 or 4111-1111-1111-1112", []string{"4111 1111 1111 1111"}},
+		{CreditCard, "4012888888881881 ts=1760702401000", []string{"4012888888881881"}},
+		{IPv4, "from 10.0.0.12 and 999.1.1.1 version 1.2.3", []string{"10.0.0.12"}},
+		{IPv6, "from 2001:db8::1 at 12:00:01", []string{"2001:db8::1"}},
+		{JWT, "token eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiIxIn0.sig_-1", []string{"eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiIxIn0.sig_-1"}},
+		{BearerToken, "Authorization: Bearer abc.DEF-123", []string{"Bearer abc.DEF-123"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.detector.Name, func(t *testing.T) {
+			var res []string
+			for _, m := range tc.detector.FindAll(tc.input) {
+				res = append(res, tc.input[m[0]:m[1]])
+			}
+			require.Equal(t, tc.exp, res)
+		})
+	}
+}
+
+func TestRedact(t *testing.T) {
+	const line = `user=alice@example.com msg="login from 10.0.0.12 by bob@example.com" password=hunter2 api_token=abc debug`
+
+	testCases := []struct {
+		rules []string
+		input string
+		exp   string
+	}{
+		{
+			[]string{"mask:key=password", "drop:key=*token*"},
+			line,
+			`user=alice@example.com msg="login from 10.0.0.12 by bob@example.com" password=[REDACTED] debug`,
+		},
+		{
+			[]string{"mask:detect=email"},
+			line,
+			`user=[REDACTED] msg="login from 10.0.0.12 by [REDACTED]" password=hunter2 api_token=abc debug`,
+		},
+		{
+			[]string{"hash:detect=email"},
+			line,
+			`user=h:a398d49ce1980b36 msg="login from 10.0.0.12 by h:19d2874a5656a443" password=hunter2 api_token=abc debug`,
+		},
+		{
+			[]string{"hash:key=user"},
+			line,
+			`user=h:a398d49ce1980b36 msg="login from 10.0.0.12 by bob@example.com" password=hunter2 api_token=abc debug`,
+		},
+		{
+			[]string{"drop:key=msg,detect=ipv4", "mask:key=debug"},
+			line,
+			`user=alice@example.com password=hunter2 api_token=abc debug`,
+		},
+		{
+			[]string{"mask:value=[a-z]+@"},
+			line,
+			`user=[REDACTED]example.com msg="login from 10.0.0.12 by [REDACTED]example.com" password=hunter2 api_token=abc debug`,
+		},
+		{
+			[]string{"drop:key=debug"},
+			line,
+			`user=alice@example.com msg="login from 10.0.0.12 by bob@example.com" password=hunter2 api_token=abc`,
+		},
+		// free text is parsed as bare keys, which are searched together
+		{
+			nil,
+			`level=info user bob@example.com logged in from 10.1.2.3 card 4111111111111111`,
+			`level=info user [REDACTED] logged in from [REDACTED] card [REDACTED]`,
+		},
+		{
+			nil,
+			`Bearer abc123 token=xyz`,
+			`[REDACTED] token=[REDACTED]`,
+		},
+		{
+			nil,
+			`card 4111 1111 1111 1111 status=ok`,
+			`card [REDACTED] status=ok`,
+		},
+		{
+			nil,
+			`bob@example.com=1 ok`,
+			`[REDACTED]=1 ok`,
+		},
+		{
+			[]string{"drop:detect=ipv4", "drop:key=retry"},
+			`retry from 10.1.2.3 now retry=2`,
+			`from now`,
+		},
+		{
+			[]string{"hash:detect=email"},
+			`mail to bob@example.com`,
+			`mail to h:19d2874a5656a443`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			r := Redactor{HashKey: []byte("secret")}
+			for _, s := range tc.rules {
+				rule, err := ParseRule(s)
+				require.NoError(t, err)
+				r.Rules = append(r.Rules, rule)
+			}
+			if len(r.Rules) == 0 {
+				r.Rules = DefaultRules()
+			}
+
+			parser := logfmt.PairParser{KeepRaw: true}
+			pairs := r.Redact(parser.Split(tc.input))
+			require.Equal(t, tc.exp, pairs.FormatPreserve())
+		})
+	}
+}
+
+func TestRedactMoreWords(t *testing.T) {
+	r := Redactor{Rules: DefaultRules(), Mask: "<a secret>"}
+
+	pairs := r.Redact(logfmt.Split(`a b@example.com c x=1 d`))
+	require.Equal(t, `a <a secret> c x=1 d`, pairs.Format())
+}
+
+func TestRedactHashIsKeyed(t *testing.T) {
+	pairs := func(key string) logfmt.Pairs {
+		r := Redactor{
+			Rules:   []Rule{{Key: "user", Action: Hash}},
+			HashKey: []byte(key),
+		}
+		return r.Redact(logfmt.Split("user=alice"))
+	}
+
+	require.Equal(t, pairs("a"), pairs("a"))
+	require.NotEqual(t, pairs("a"), pairs("b"))
+}
+
+func TestDefaultRules(t *testing.T) {
+	r := Redactor{Rules: DefaultRules(), Mask: "***"}
+
+	pairs := r.Redact(logfmt.Split(`db_password=x Authorization="Basic dXNlcg==" authorization="Basic dXNlcg==" ip=10.1.2.3 status=200`))
+	require.Equal(t, `db_password=*** Authorization=*** authorization=*** ip=*** status=200`, pairs.Format())
+
+	pairs = r.Redact(logfmt.Split(`Password=x Authorization=y API_KEY=z Cookie=w X-Auth-Token=v Status=200`))
+	require.Equal(t, `Password=*** Authorization=*** API_KEY=*** Cookie=*** X-Auth-Token=*** Status=200`, pairs.Format())
+}
@@ -0,0 +1,259 @@
+package logfmt
+
+import (
+	"encoding/json"
+	"hash/maphash"
+	"math"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ValueType is the type of a value, as inferred from its text.
+type ValueType uint8
+
+// TypeNull is the type of a null value, an empty value or the text "null". It is not a flag: a ValueType
+// combining the types of several values is TypeNull only if they are all null.
+const TypeNull ValueType = 0
+
+const (
+	TypeBool ValueType = 1 << iota
+	TypeInt
+	TypeFloat
+	TypeDuration
+	TypeTime
+	TypeJSON
+	TypeString
+)
+
+var valueTypeNames = []struct {
+	t    ValueType
+	name string
+}{
+	{TypeBool, "bool"},
+	{TypeInt, "int"},
+	{TypeFloat, "float"},
+	{TypeDuration, "duration"},
+	{TypeTime, "time"},
+	{TypeJSON, "json"},
+	{TypeString, "string"},
+}
+
+// String returns the names of the types in t separated by '|', like "int|float".
+func (t ValueType) String() string {
+	var names []string
+	for _, v := range valueTypeNames {
+		if t&v.t != 0 {
+			names = append(names, v.name)
+		}
+	}
+	if len(names) == 0 {
+		return "null"
+	}
+	return strings.Join(names, "|")
+}
+
+// InferType returns the most specific type the value of the pair can be parsed as.
+// Times are parsed with DefaultTimeLayouts and a bare key is a bool.
+//
+// It returns TypeNull for an empty value or the text "null".
+func InferType(pair Pair) ValueType {
+	s := pair.Value
+
+	switch {
+	case pair.Bare:
+		return TypeBool
+	case s == "" || s == "null":
+		return TypeNull
+	case s == "true" || s == "false":
+		return TypeBool
+	case looksNumeric(s):
+		if _, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return TypeInt
+		}
+		if _, err := strconv.ParseFloat(s, 64); err == nil {
+			return TypeFloat
+		}
+		if _, err := time.ParseDuration(s); err == nil {
+			return TypeDuration
+		}
+	case (s[0] == '{' || s[0] == '[') && json.Valid([]byte(s)):
+		return TypeJSON
+	}
+
+	if _, err := pair.Time(); err == nil {
+		return TypeTime
+	}
+
+	return TypeString
+}
+
+// MaxSchemaExamples is the number of distinct example values kept for each field of a Schema.
+const MaxSchemaExamples = 3
+
+// maxExactCardinality is the number of distinct values counted exactly before switching to an estimate.
+const maxExactCardinality = 1000
+
+// Schema describes the fields of a stream of records. Records are added with Add.
+// The zero value is an empty schema ready to use.
+type Schema struct {
+	// Records is the number of records added.
+	Records int
+	// Fields are the fields seen in the records, in order of first appearance.
+	Fields []*FieldSchema
+
+	index map[string]*FieldSchema
+	seed  maphash.Seed
+}
+
+// FieldSchema describes the values of a key in a stream of records.
+type FieldSchema struct {
+	Key string
+	// Types are all the types of the values seen, see InferType.
+	Types ValueType
+	// Count is the number of records with the key.
+	Count int
+	// Missing is the number of records without the key.
+	Missing int
+	// Nulls is the number of null values: empty values or the text "null".
+	Nulls int
+	// Examples are the first distinct non-null values seen, up to MaxSchemaExamples.
+	Examples []string
+
+	distinct  map[string]struct{}
+	registers [hllRegisters]uint8
+	lastSeen  int
+}
+
+// Add adds the pairs of a record to the schema.
+// If a key appears multiple times in the record, every value is taken into account but the record is counted once.
+func (s *Schema) Add(pairs Pairs) {
+	if s.index == nil {
+		s.index = make(map[string]*FieldSchema)
+		s.seed = maphash.MakeSeed()
+	}
+	s.Records++
+
+	for _, pair := range pairs {
+		f, ok := s.index[pair.Key]
+		if !ok {
+			f = &FieldSchema{
+				Key:      pair.Key,
+				Missing:  s.Records - 1,
+				distinct: make(map[string]struct{}),
+			}
+			s.index[pair.Key] = f
+			s.Fields = append(s.Fields, f)
+		}
+
+		if f.lastSeen != s.Records {
+			f.lastSeen = s.Records
+			f.Count++
+		}
+		f.add(s.seed, pair)
+	}
+
+	for _, f := range s.Fields {
+		if f.lastSeen != s.Records {
+			f.Missing++
+		}
+	}
+}
+
+// Field returns the schema of the field with the key, or nil if the key was never seen.
+func (s *Schema) Field(key string) *FieldSchema {
+	return s.index[key]
+}
+
+func (f *FieldSchema) add(seed maphash.Seed, pair Pair) {
+	typ := InferType(pair)
+	if typ == TypeNull {
+		f.Nulls++
+		return
+	}
+	f.Types |= typ
+
+	value := pair.Value
+	if pair.Bare {
+		value = "true"
+	}
+
+	if f.distinct != nil {
+		if _, ok := f.distinct[value]; !ok {
+			if len(f.Examples) < MaxSchemaExamples {
+				f.Examples = append(f.Examples, value)
+			}
+			f.distinct[value] = struct{}{}
+		}
+		if len(f.distinct) > maxExactCardinality {
+			f.distinct = nil
+		}
+	}
+
+	f.addHash(maphash.String(seed, value))
+}
+
+// Type returns the single type describing all the values of the field:
+// its type if all values have the same, float if they are integers or floats and string otherwise.
+func (f *FieldSchema) Type() ValueType {
+	switch f.Types {
+	case TypeBool, TypeInt, TypeFloat, TypeDuration, TypeTime, TypeJSON:
+		return f.Types
+	case TypeInt | TypeFloat:
+		return TypeFloat
+	default:
+		return TypeString
+	}
+}
+
+// Nullable returns true if some records don't have the key or have a null value for it.
+func (f *FieldSchema) Nullable() bool {
+	return f.Missing > 0 || f.Nulls > 0
+}
+
+// Cardinality returns the number of distinct non-null values of the field.
+// It is exact up to a thousand values and an estimate with a typical error of 3% above.
+func (f *FieldSchema) Cardinality() uint64 {
+	if f.distinct != nil {
+		return uint64(len(f.distinct))
+	}
+	return f.estimate()
+}
+
+// The estimate is a HyperLogLog with 2^hllPrecision registers.
+const (
+	hllPrecision = 10
+	hllRegisters = 1 << hllPrecision
+)
+
+func (f *FieldSchema) addHash(h uint64) {
+	idx := h >> (64 - hllPrecision)
+	rank := uint8(bits.LeadingZeros64(h<<hllPrecision|1<<(hllPrecision-1)) + 1)
+	if rank > f.registers[idx] {
+		f.registers[idx] = rank
+	}
+}
+
+func (f *FieldSchema) estimate() uint64 {
+	const m = float64(hllRegisters)
+
+	var (
+		sum   float64
+		zeros int
+	)
+	for _, r := range f.registers {
+		sum += 1 / float64(uint64(1)<<r)
+		if r == 0 {
+			zeros++
+		}
+	}
+
+	alpha := 0.7213 / (1 + 1.079/m)
+	e := alpha * m * m / sum
+	if e <= 2.5*m && zeros > 0 {
+		e = m * math.Log(m/float64(zeros))
+	}
+
+	return uint64(e + 0.5)
+}
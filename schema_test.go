@@ -0,0 +1,110 @@
+package logfmt
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInferType(t *testing.T) {
+	testCases := []struct {
+		input string
+		exp   ValueType
+	}{
+		{"a=true", TypeBool},
+		{"a", TypeBool},
+		{"a=12", TypeInt},
+		{"a=-12", TypeInt},
+		{"a=1.5", TypeFloat},
+		{"a=1e6", TypeFloat},
+		{"a=1m30s", TypeDuration},
+		{"a=2026-10-17T12:00:01Z", TypeTime},
+		{`a="{\"b\":1}"`, TypeJSON},
+		{`a=[1,2]`, TypeJSON},
+		{`a={foo`, TypeString},
+		{"a=NaN", TypeString},
+		{"a=12abc", TypeString},
+		{"a=foo", TypeString},
+		{"a=", TypeNull},
+		{"a=null", TypeNull},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			require.Equal(t, tc.exp, InferType(Split(tc.input)[0]))
+		})
+	}
+}
+
+func TestSchema(t *testing.T) {
+	lines := []string{
+		`time=2026-10-17T12:00:01Z level=info status=200 elapsed=1.5 cached`,
+		`time=2026-10-17T12:00:02Z level=info status=404 elapsed=2 user=`,
+		`time=2026-10-17T12:00:03Z level=error status=500 elapsed=3ms err="boom" user=bob`,
+		`time=2026-10-17T12:00:04Z level=info status=200 elapsed=4 tag=a tag=b`,
+	}
+
+	var schema Schema
+	for _, line := range lines {
+		schema.Add(Split(line))
+	}
+
+	require.Equal(t, 4, schema.Records)
+
+	var keys []string
+	for _, f := range schema.Fields {
+		keys = append(keys, f.Key)
+	}
+	require.Equal(t, []string{"time", "level", "status", "elapsed", "cached", "user", "err", "tag"}, keys)
+
+	f := schema.Field("status")
+	require.Equal(t, TypeInt, f.Type())
+	require.False(t, f.Nullable())
+	require.Equal(t, 4, f.Count)
+	require.Equal(t, uint64(3), f.Cardinality())
+	require.Equal(t, []string{"200", "404", "500"}, f.Examples)
+
+	f = schema.Field("elapsed")
+	require.Equal(t, TypeInt|TypeFloat|TypeDuration, f.Types)
+	require.Equal(t, "int|float|duration", f.Types.String())
+	require.Equal(t, "null", TypeNull.String())
+	require.Equal(t, TypeString, f.Type())
+
+	f = schema.Field("time")
+	require.Equal(t, TypeTime, f.Type())
+	require.Equal(t, uint64(4), f.Cardinality())
+
+	f = schema.Field("level")
+	require.Equal(t, TypeString, f.Type())
+	require.Equal(t, uint64(2), f.Cardinality())
+
+	f = schema.Field("user")
+	require.True(t, f.Nullable())
+	require.Equal(t, 2, f.Count)
+	require.Equal(t, 2, f.Missing)
+	require.Equal(t, 1, f.Nulls)
+	require.Equal(t, []string{"bob"}, f.Examples)
+
+	f = schema.Field("cached")
+	require.Equal(t, TypeBool, f.Type())
+	require.Equal(t, 3, f.Missing)
+
+	f = schema.Field("tag")
+	require.Equal(t, 1, f.Count)
+	require.Equal(t, 3, f.Missing)
+	require.Equal(t, uint64(2), f.Cardinality())
+
+	require.Nil(t, schema.Field("foobar"))
+}
+
+func TestSchemaCardinalityEstimate(t *testing.T) {
+	var schema Schema
+	for i := 0; i < 50000; i++ {
+		schema.Add(Pairs{{Key: "id", Value: strconv.Itoa(i % 20000)}})
+	}
+
+	f := schema.Field("id")
+	require.Equal(t, TypeInt, f.Type())
+	require.InEpsilon(t, 20000, float64(f.Cardinality()), 0.1)
+}
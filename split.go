@@ -15,15 +15,36 @@ func Split(line string) Pairs {
 }
 
 // PairParser is a parser of key-value pairs. It parses a logline according to the logfmt rules.
+//
+// The zero value is a lenient parser: when it encounters a malformed pair it stops parsing and returns
+// the pairs parsed so far.
 type PairParser struct {
+	// Strict makes the parser report malformed pairs as a *SyntaxError instead of silently stopping.
+	// The error is returned by Parse and ParseInto.
+	Strict bool
+	// ReplaceInvalidUTF8 makes the parser replace bytes which are not valid UTF-8 with utf8.RuneError.
+	// By default they are preserved as is in keys and values.
+	ReplaceInvalidUTF8 bool
+	// Duplicates defines what to do with keys appearing multiple times in a line. By default all pairs are kept.
+	Duplicates DuplicatePolicy
+	// KeepRaw makes the parser keep the original text of each pair in Pair.Raw.
+	KeepRaw bool
+
 	data string
 	cur  string
 
 	buf  *bytes.Buffer
 	done bool
+	err  error
+
+	pairStart int
+	keyStart  int
+	lossy     bool
+	last      string
 
 	pairs       Pairs
 	currentPair Pair
+	seen        map[string]int
 }
 
 // Split splits a log line according to the logfmt rules and produces key-value pairs.
@@ -34,128 +55,261 @@ func (p *PairParser) Split(line string) Pairs {
 
 // SplitInto splits a log line according to the logfmt rules and produces key-value pairs.
 // This function appends the pairs to `pairs` and return the slice truncated.
+//
+// In strict mode the pairs parsed before the first syntax error are returned; use ParseInto to get the error.
 func (p *PairParser) SplitInto(line string, pairs Pairs) Pairs {
+	return p.splitAppend(line, pairs[:0])
+}
+
+// splitAppend works like SplitInto but appends to `pairs` without truncating it first.
+func (p *PairParser) splitAppend(line string, pairs Pairs) Pairs {
 	if p.buf == nil {
 		p.buf = new(bytes.Buffer)
 	}
 	p.done = false
+	p.err = nil
 
 	p.data = line
 	p.cur = line
 
-	p.pairs = pairs[:0]
+	p.pairs = pairs
 
 	for !p.done {
-		p.readKey()
-		p.readValue()
+		if p.readKey() {
+			p.readValue()
+		}
 	}
 
+	p.pairs = p.applyDuplicatePolicy(p.pairs)
+
 	return p.pairs
 }
 
-func (p *PairParser) maybeMoveBufToValue(unquote bool) {
+// Parse splits a log line according to the logfmt rules and produces key-value pairs.
+// If the parser is in strict mode it returns a *SyntaxError when the line is malformed, along with the pairs parsed before the error.
+func (p *PairParser) Parse(line string) (Pairs, error) {
+	var pairs Pairs
+	return p.ParseInto(line, pairs)
+}
+
+// ParseInto works like SplitInto but also returns the syntax error encountered in strict mode, if any.
+func (p *PairParser) ParseInto(line string, pairs Pairs) (Pairs, error) {
+	pairs = p.SplitInto(line, pairs)
+	return pairs, p.err
+}
+
+// fail stops the parsing. In strict mode it records a syntax error at `offset`.
+func (p *PairParser) fail(offset int, reason string) {
+	p.done = true
+	if p.Strict && p.err == nil {
+		p.err = &SyntaxError{
+			Offset: offset,
+			Key:    p.currentPair.Key,
+			Reason: reason,
+		}
+	}
+}
+
+// offset returns the current position of the parser in the line.
+func (p *PairParser) offset() int {
+	return len(p.data) - len(p.cur)
+}
+
+// moveBufToValue adds the current pair, whose original text ends at offset `end`.
+func (p *PairParser) moveBufToValue(end int) {
 	p.currentPair.Value = p.buf.String()
-	if unquote {
-		p.currentPair.Value, _ = strconv.Unquote(p.currentPair.Value)
+	p.appendCurrentPair(end)
+}
+
+func (p *PairParser) appendCurrentPair(end int) {
+	if p.KeepRaw && !p.lossy {
+		p.currentPair.Raw = p.data[p.pairStart:end]
 	}
 	p.pairs = append(p.pairs, p.currentPair)
 }
 
-func (p *PairParser) readKey() {
+// readKey reads the next key. It returns true if the key is followed by a value to read.
+//
+// A key not followed by '=' is a bare key: it is added immediately with no value.
+func (p *PairParser) readKey() bool {
+	p.pairStart = p.offset()
+	p.consumeWhitespace()
+
 	if p.cur == "" {
 		p.done = true
-		return
+		return false
 	}
 
-	p.consumeWhitespace()
+	p.currentPair = Pair{}
+	p.lossy = false
 
-	p.currentPair.Key = ""
-	p.currentPair.Value = ""
+	p.keyStart = p.offset()
+	keyStart := p.keyStart
 
-	pos := strings.IndexRune(p.cur, '=')
-	if pos == -1 || len(p.cur[pos+1:]) == 0 {
-		p.done = true
-		return
+	pos := strings.IndexAny(p.cur, "= ")
+	if pos == -1 || p.cur[pos] == ' ' {
+		if pos == -1 {
+			pos = len(p.cur)
+		}
+
+		p.currentPair.Key = p.valid(p.cur[:pos])
+		p.currentPair.Bare = true
+		p.appendCurrentPair(keyStart + pos)
+
+		p.cur = p.cur[pos:]
+		return false
 	}
 
-	p.currentPair.Key = p.cur[:pos]
+	if pos == 0 && p.Strict {
+		p.fail(keyStart, reasonEmptyKey)
+		return false
+	}
+
+	p.currentPair.Key = p.valid(p.cur[:pos])
 	p.cur = p.cur[pos+1:]
+
+	return true
 }
 
 func (p *PairParser) readValue() {
-	if p.done {
-		return
-	}
-
 	p.buf.Reset()
 
 	for {
 		ch := p.readRune()
 		switch ch {
 		case eof:
-			p.maybeMoveBufToValue(false)
+			if p.err == nil {
+				p.moveBufToValue(p.offset())
+			}
 			p.done = true
 			return
 		case ' ':
-			p.maybeMoveBufToValue(false)
+			// Leave the space to the next pair's whitespace
+			p.cur = p.data[p.offset()-1:]
+			p.moveBufToValue(p.offset())
 			return
 		case '"':
+			if p.buf.Len() > 0 {
+				if p.Strict {
+					p.fail(p.keyStart, reasonQuoteInValue)
+					return
+				}
+				// The text before the quote is dropped
+				p.lossy = true
+			}
 			p.readQuotedValue()
 			return
 		default:
-			p.buf.WriteRune(ch)
+			p.buf.WriteString(p.last)
 		}
 	}
 }
 
 // readQuotedValue reads a value that is double-quoted.
-// Leverages https://golang.org/pkg/strconv/#Unquote.
+// Escape sequences are decoded with https://golang.org/pkg/strconv/#UnquoteChar, everything else is kept as is.
 func (p *PairParser) readQuotedValue() {
 	p.buf.Reset()
 
-	p.buf.WriteRune('"')
+	quoteStart := p.offset() - 1
+	invalid := false
 
 	for {
 		ch := p.readRune()
 		switch {
 		case ch == eof:
-			p.maybeMoveBufToValue(false)
+			if p.err == nil {
+				if p.Strict {
+					p.fail(p.keyStart, reasonUnterminatedQuote)
+					return
+				}
+
+				// Keep the raw text of the value, opening quote included.
+				p.buf.Reset()
+				p.buf.WriteString(p.valid(p.data[quoteStart:]))
+				p.moveBufToValue(p.offset())
+			}
 			p.done = true
 			return
+
 		case ch == '\\':
-			nextCh := p.readRune()
-			if nextCh != '\'' {
-				p.buf.WriteRune(ch)
-				p.buf.WriteRune(nextCh)
+			if strings.HasPrefix(p.cur, "'") {
+				p.cur = p.cur[1:]
+				p.buf.WriteByte('\'')
+				continue
+			}
+
+			value, multibyte, tail, err := strconv.UnquoteChar(p.data[p.offset()-1:], '"')
+			if err != nil {
+				if p.Strict {
+					p.fail(p.keyStart, reasonInvalidQuotedValue)
+					return
+				}
+				invalid = true
+				p.lossy = true
+				continue
+			}
+			p.cur = tail
+
+			if value < utf8.RuneSelf || !multibyte {
+				p.buf.WriteByte(byte(value))
 			} else {
-				p.buf.WriteRune(nextCh)
+				p.buf.WriteRune(value)
 			}
+
 		case ch == '"':
-			p.buf.WriteRune(ch)
-			p.maybeMoveBufToValue(true)
+			if p.cur != "" && p.cur[0] != ' ' && p.Strict {
+				p.fail(p.keyStart, reasonMissingSeparator)
+				return
+			}
+			if invalid {
+				p.buf.Reset()
+			}
+			p.moveBufToValue(p.offset())
 			return
+
 		default:
-			p.buf.WriteRune(ch)
+			p.buf.WriteString(p.last)
 		}
 	}
 }
 
-var eof = rune(0)
+var eof = rune(-1)
 
+// readRune reads the next rune and keeps its bytes in p.last.
+//
+// Bytes which are not valid UTF-8 are returned as utf8.RuneError one at a time, p.last holding either the byte itself
+// or the replacement character if ReplaceInvalidUTF8 is set.
 func (p *PairParser) readRune() rune {
-	ch, n := utf8.DecodeRuneInString(p.cur[0:])
-	if ch == utf8.RuneError {
-		p.cur = ""
+	ch, n := utf8.DecodeRuneInString(p.cur)
+	if n == 0 {
 		return eof
 	}
+
+	p.last = p.cur[:n]
 	p.cur = p.cur[n:]
 
+	if ch == utf8.RuneError && n == 1 && p.ReplaceInvalidUTF8 {
+		p.last = string(utf8.RuneError)
+	}
+
 	return ch
 }
 
+// valid returns s with its invalid UTF-8 bytes replaced if ReplaceInvalidUTF8 is set.
+func (p *PairParser) valid(s string) string {
+	if p.ReplaceInvalidUTF8 && !utf8.ValidString(s) {
+		return strings.ToValidUTF8(s, string(utf8.RuneError))
+	}
+	return s
+}
+
 func (p *PairParser) consumeWhitespace() {
 	idx := strings.IndexFunc(p.cur, func(r rune) bool {
 		return r != ' '
 	})
+	if idx == -1 {
+		p.cur = ""
+		return
+	}
 	p.cur = p.cur[idx:]
 }
@@ -0,0 +1,269 @@
+package logfmt
+
+import (
+	"bytes"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Span is the range of bytes [Start, End) of a line.
+type Span struct {
+	Start, End int
+}
+
+// PairSpan is a key-value pair described by its position in a line instead of copies of its key and value.
+type PairSpan struct {
+	Key   Span
+	Value Span
+	// Bare is true if the key is not followed by '='. The value span is empty.
+	Bare bool
+	// Decoded holds the value if it can't be taken as is from the line, because it is a quoted value
+	// containing escape sequences or because duplicate values were merged. It is nil otherwise.
+	Decoded []byte
+}
+
+// KeyBytes returns the key of the pair. It is a subslice of line.
+func (s *PairSpan) KeyBytes(line []byte) []byte {
+	return line[s.Key.Start:s.Key.End]
+}
+
+// ValueBytes returns the value of the pair. It is a subslice of line unless Decoded is set.
+func (s *PairSpan) ValueBytes(line []byte) []byte {
+	if s.Decoded != nil {
+		return s.Decoded
+	}
+	return line[s.Value.Start:s.Value.End]
+}
+
+// Pair returns a copy of the pair.
+func (s *PairSpan) Pair(line []byte) Pair {
+	return Pair{
+		Key:   string(s.KeyBytes(line)),
+		Value: string(s.ValueBytes(line)),
+		Bare:  s.Bare,
+	}
+}
+
+// SplitBytes splits a log line according to the logfmt rules and produces the position of its key-value pairs.
+// It is a convenience function which does the same as PairParser.SplitBytes(line).
+func SplitBytes(line []byte) []PairSpan {
+	var parser PairParser
+	return parser.SplitBytes(line)
+}
+
+// SplitBytes splits a log line according to the logfmt rules and produces the position of its key-value pairs.
+func (p *PairParser) SplitBytes(line []byte) []PairSpan {
+	var spans []PairSpan
+	return p.SplitBytesInto(line, spans)
+}
+
+// SplitBytesInto works like SplitInto but produces spans pointing into line instead of copying the keys and values.
+// This function appends the spans to `spans` and return the slice truncated.
+//
+// Nothing is allocated, except for growing `spans` and for the values which must be decoded; see PairSpan.Decoded.
+// The spans are only valid as long as line is not modified.
+//
+// Keys and values are the same as the ones produced by Split, including in strict mode and with a duplicate policy,
+// except that ReplaceInvalidUTF8 is ignored: the bytes of the line are always returned as is.
+func (p *PairParser) SplitBytesInto(line []byte, spans []PairSpan) []PairSpan {
+	spans = spans[:0]
+
+	for i := 0; ; {
+		span, next, ok := nextSpan(p, line, i)
+		if !ok {
+			break
+		}
+		spans = append(spans, span)
+		i = next
+	}
+
+	return p.applySpanDuplicatePolicy(line, spans)
+}
+
+// nextSpan reads the pair starting at position i, after optional whitespace.
+// It returns the pair and the position after it, or false if there is no pair left or the parsing must stop.
+// It works on strings too so that All doesn't have to convert its line.
+func nextSpan[T string | []byte](p *PairParser, line T, i int) (PairSpan, int, bool) {
+	n := len(line)
+
+	for i < n && line[i] == ' ' {
+		i++
+	}
+	if i >= n {
+		return PairSpan{}, n, false
+	}
+
+	// Key
+
+	keyStart := i
+	for i < n && line[i] != '=' && line[i] != ' ' {
+		i++
+	}
+	if i == n || line[i] == ' ' {
+		return PairSpan{
+			Key:   Span{keyStart, i},
+			Value: Span{i, i},
+			Bare:  true,
+		}, i, true
+	}
+	if i == keyStart && p.Strict {
+		return PairSpan{}, i, false
+	}
+
+	span := PairSpan{Key: Span{keyStart, i}}
+	i++
+
+	// Value. Like readValue a double quote starts a quoted value even in the middle of an unquoted one.
+
+	valueStart := i
+	for i < n && line[i] != ' ' && line[i] != '"' {
+		i++
+	}
+	if i == n || line[i] == ' ' {
+		span.Value = Span{valueStart, i}
+		return span, i, true
+	}
+	if i > valueStart && p.Strict {
+		return PairSpan{}, i, false
+	}
+
+	i, ok := readQuotedSpan(p, line, i, &span)
+
+	return span, i, ok
+}
+
+// readQuotedSpan reads the quoted value starting at the double quote at position i and fills span.
+// It returns the position after the value and false if the parsing must stop.
+func readQuotedSpan[T string | []byte](p *PairParser, line T, i int, span *PairSpan) (int, bool) {
+	n := len(line)
+	quoteStart := i
+	i++
+
+	start := i
+	var (
+		decoded []byte
+		invalid bool
+	)
+
+	for i < n {
+		switch ch := line[i]; ch {
+		case '"':
+			if i+1 < n && line[i+1] != ' ' && p.Strict {
+				return i, false
+			}
+			switch {
+			case invalid:
+				span.Value = Span{start, start}
+				span.Decoded = []byte{}
+			case decoded != nil:
+				span.Value = Span{start, i}
+				span.Decoded = decoded
+			default:
+				span.Value = Span{start, i}
+			}
+			return i + 1, true
+
+		case '\\':
+			if decoded == nil {
+				decoded = make([]byte, 0, i-start+utf8.UTFMax)
+				decoded = append(decoded, line[start:i]...)
+			}
+
+			if i+1 < n && line[i+1] == '\'' {
+				decoded = append(decoded, '\'')
+				i += 2
+				continue
+			}
+
+			// The longest escape sequence is \U0010FFFF: no need to convert the rest of the line.
+			end := i + 10
+			if end > n {
+				end = n
+			}
+			s := string(line[i:end])
+
+			value, multibyte, tail, err := strconv.UnquoteChar(s, '"')
+			if err != nil {
+				if p.Strict {
+					return i, false
+				}
+				invalid = true
+				i++
+				continue
+			}
+			i += len(s) - len(tail)
+
+			if value < utf8.RuneSelf || !multibyte {
+				decoded = append(decoded, byte(value))
+			} else {
+				decoded = utf8.AppendRune(decoded, value)
+			}
+
+		default:
+			if decoded != nil {
+				decoded = append(decoded, ch)
+			}
+			i++
+		}
+	}
+
+	if p.Strict {
+		return n, false
+	}
+
+	// Keep the raw text of the value, opening quote included.
+	span.Value = Span{quoteStart, n}
+
+	return n, true
+}
+
+// applySpanDuplicatePolicy is the equivalent of applyDuplicatePolicy for spans.
+func (p *PairParser) applySpanDuplicatePolicy(line []byte, spans []PairSpan) []PairSpan {
+	if p.Duplicates == KeepAllDuplicates || len(spans) < 2 {
+		return spans
+	}
+
+	var merged []bool // merged[j] is true if res[j].Decoded is a list of merged values
+
+	res := spans[:0]
+	for _, span := range spans {
+		key := span.KeyBytes(line)
+
+		j := -1
+		for k := range res {
+			if bytes.Equal(res[k].KeyBytes(line), key) {
+				j = k
+				break
+			}
+		}
+
+		switch {
+		case j == -1:
+			res = append(res, span)
+		case p.Duplicates == LastDuplicateWins:
+			res[j] = span
+		case p.Duplicates == MergeDuplicates:
+			if merged == nil {
+				merged = make([]bool, len(spans))
+			}
+
+			var list []byte
+			switch {
+			case merged[j] && !res[j].Bare:
+				list = res[j].Decoded
+			case !merged[j] && !res[j].Bare:
+				list = appendMergedValue(nil, string(res[j].ValueBytes(line)))
+			}
+			merged[j] = true
+
+			if !span.Bare {
+				list = appendMergedValue(list, string(span.ValueBytes(line)))
+			}
+			if len(list) > 0 {
+				res[j].Decoded, res[j].Bare = list, false
+			}
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,128 @@
+package logfmt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func spansToPairs(line []byte, spans []PairSpan) Pairs {
+	var pairs Pairs
+	for i := range spans {
+		pairs = append(pairs, spans[i].Pair(line))
+	}
+	return pairs
+}
+
+func TestSplitBytesSameAsSplit(t *testing.T) {
+	testCases := []string{
+		"",
+		"   ",
+		"ab=cd",
+		"foo=bar 1=2    a=b   ",
+		`str="foo bar baz" json="{\"Foo\":\"foo\",\"Bar\":\"bar\",\"Baz\":{\"A\":12}}"`,
+		`foo="Can\'t do this" bar="\u00e9\U0001F600\x41\101\n\t"`,
+		`foo="bar" tags= bar=baz`,
+		"debug key= next=1 retry",
+		`a=b msg="foo \q" c=d =e`,
+		`a=b msg="foo bar`,
+		`a=foo"bar baz" b=1`,
+		`a="x"b=1`,
+		"a=foo\xffbar \xffbare msg=\"caf\xe9 \\xff\"",
+		`a=1 b=2 a=3 a b="4 5" b="\"6\""`,
+	}
+
+	for _, policy := range []DuplicatePolicy{KeepAllDuplicates, FirstDuplicateWins, LastDuplicateWins, MergeDuplicates} {
+		for _, strict := range []bool{false, true} {
+			parser := PairParser{Strict: strict, Duplicates: policy}
+
+			for _, tc := range testCases {
+				t.Run("", func(t *testing.T) {
+					exp := parser.Split(tc)
+					if len(exp) == 0 {
+						exp = nil
+					}
+
+					line := []byte(tc)
+					require.Equal(t, exp, spansToPairs(line, parser.SplitBytes(line)))
+				})
+			}
+		}
+	}
+}
+
+func TestSplitBytesSpans(t *testing.T) {
+	line := []byte(`a=1 msg="foo bar" esc="a\"b" bare`)
+
+	spans := SplitBytes(line)
+	require.Equal(t, []PairSpan{
+		{Key: Span{0, 1}, Value: Span{2, 3}},
+		{Key: Span{4, 7}, Value: Span{9, 16}},
+		{Key: Span{18, 21}, Value: Span{23, 27}, Decoded: []byte(`a"b`)},
+		{Key: Span{29, 33}, Value: Span{33, 33}, Bare: true},
+	}, spans)
+
+	require.Equal(t, []byte("foo bar"), spans[1].ValueBytes(line))
+	require.Equal(t, []byte(`a"b`), spans[2].ValueBytes(line))
+	require.Equal(t, []byte("bare"), spans[3].KeyBytes(line))
+}
+
+func TestSplitBytesIntoAllocations(t *testing.T) {
+	line := []byte(`city=Lyon name=Vincent age=123 str="foo bar baz" debug`)
+
+	var parser PairParser
+	spans := make([]PairSpan, 0, 16)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		spans = parser.SplitBytesInto(line, spans)
+	})
+	require.Equal(t, 0.0, allocs)
+	require.Len(t, spans, 5)
+}
+
+const benchmarkLine = `city=Lyon name=Vincent age=123 latitude=0.2982902490 longitude=95.2023904 str="foo bar baz" json="{\"Foo\":\"foo\",\"Bar\":\"bar\",\"Baz\":{\"A\":12,\"B\":4540,\"C\":{\"Opened\":true}}}"`
+
+const benchmarkLineNoEscape = `city=Lyon name=Vincent age=123 latitude=0.2982902490 longitude=95.2023904 str="foo bar baz" msg="request handled"`
+
+func BenchmarkSplitNoEscape(b *testing.B) {
+	var parser PairParser
+	pairs := make(Pairs, 0, 16)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		pairs = parser.SplitInto(benchmarkLineNoEscape, pairs)
+		if len(pairs) <= 0 {
+			b.Fatal("should have at least one pair")
+		}
+	}
+}
+
+func BenchmarkSplitBytes(b *testing.B) {
+	line := []byte(benchmarkLine)
+
+	var parser PairParser
+	spans := make([]PairSpan, 0, 16)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		spans = parser.SplitBytesInto(line, spans)
+		if len(spans) <= 0 {
+			b.Fatal("should have at least one pair")
+		}
+	}
+}
+
+func BenchmarkSplitBytesNoEscape(b *testing.B) {
+	line := []byte(benchmarkLineNoEscape)
+
+	var parser PairParser
+	spans := make([]PairSpan, 0, 16)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		spans = parser.SplitBytesInto(line, spans)
+		if len(spans) <= 0 {
+			b.Fatal("should have at least one pair")
+		}
+	}
+}
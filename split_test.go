@@ -18,42 +18,42 @@ func TestSplit(t *testing.T) {
 		{
 			"ab=cd",
 			Pairs{
-				{"ab", "cd"},
+				{Key: "ab", Value: "cd"},
 			},
 		},
 		{
 			"foo=bar 1=2    a=b",
 			Pairs{
-				{"foo", "bar"},
-				{"1", "2"},
-				{"a", "b"},
+				{Key: "foo", Value: "bar"},
+				{Key: "1", Value: "2"},
+				{Key: "a", Value: "b"},
 			},
 		},
 		{
 			`str="foo bar baz" json="{\"Foo\":\"foo\",\"Bar\":\"bar\",\"Baz\":{\"A\":12,\"B\":4540,\"C\":{\"Opened\":true}}}"`,
 			Pairs{
-				{"str", "foo bar baz"},
-				{"json", `{"Foo":"foo","Bar":"bar","Baz":{"A":12,"B":4540,"C":{"Opened":true}}}`},
+				{Key: "str", Value: "foo bar baz"},
+				{Key: "json", Value: `{"Foo":"foo","Bar":"bar","Baz":{"A":12,"B":4540,"C":{"Opened":true}}}`},
 			},
 		},
 		{
 			`json="\"{\\\"Foo\\\":\\\"foo\\\",\\\"Bar\\\":\\\"bar\\\",\\\"Baz\\\":{\\\"A\\\":12,\\\"B\\\":4540,\\\"C\\\":{\\\"Opened\\\":true}}}\""`,
 			Pairs{
-				{"json", `"{\"Foo\":\"foo\",\"Bar\":\"bar\",\"Baz\":{\"A\":12,\"B\":4540,\"C\":{\"Opened\":true}}}"`},
+				{Key: "json", Value: `"{\"Foo\":\"foo\",\"Bar\":\"bar\",\"Baz\":{\"A\":12,\"B\":4540,\"C\":{\"Opened\":true}}}"`},
 			},
 		},
 		{
 			`foo="Can\'t do this"`,
 			Pairs{
-				{"foo", "Can't do this"},
+				{Key: "foo", Value: "Can't do this"},
 			},
 		},
 		{
 			`foo="bar" tags= bar=baz`,
 			Pairs{
-				{"foo", "bar"},
-				{"tags", ""},
-				{"bar", "baz"},
+				{Key: "foo", Value: "bar"},
+				{Key: "tags", Value: ""},
+				{Key: "bar", Value: "baz"},
 			},
 		},
 	}
@@ -70,7 +70,53 @@ func TestSplitNoValue(t *testing.T) {
 	var parser PairParser
 
 	pairs := parser.Split("body=")
-	require.Empty(t, pairs)
+	require.Equal(t, Pairs{{Key: "body", Value: ""}}, pairs)
+}
+
+func TestSplitBareKeys(t *testing.T) {
+	testCases := []struct {
+		input string
+		exp   Pairs
+	}{
+		{
+			"retry",
+			Pairs{
+				{Key: "retry", Bare: true},
+			},
+		},
+		{
+			"debug key= next=1",
+			Pairs{
+				{Key: "debug", Bare: true},
+				{Key: "key", Value: ""},
+				{Key: "next", Value: "1"},
+			},
+		},
+		{
+			`a=1 retry  dry-run msg="foo bar"`,
+			Pairs{
+				{Key: "a", Value: "1"},
+				{Key: "retry", Bare: true},
+				{Key: "dry-run", Bare: true},
+				{Key: "msg", Value: "foo bar"},
+			},
+		},
+		{
+			"a= b=",
+			Pairs{
+				{Key: "a", Value: ""},
+				{Key: "b", Value: ""},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			res := Split(tc.input)
+			require.Equal(t, tc.exp, res)
+			require.Equal(t, tc.exp, Split(res.Format()))
+		})
+	}
 }
 
 func TestSplitInto(t *testing.T) {
@@ -132,3 +178,135 @@ func BenchmarkSplitFile(b *testing.B) {
 		}
 	}
 }
+
+func TestSplitTrailingWhitespace(t *testing.T) {
+	pairs := Split("a=b   ")
+	require.Equal(t, Pairs{{Key: "a", Value: "b"}}, pairs)
+}
+
+func TestParseStrict(t *testing.T) {
+	testCases := []struct {
+		input string
+		exp   Pairs
+		err   *SyntaxError
+	}{
+		{
+			`a=b c="d e"`,
+			Pairs{{Key: "a", Value: "b"}, {Key: "c", Value: "d e"}},
+			nil,
+		},
+		{
+			"a=b debug c=",
+			Pairs{{Key: "a", Value: "b"}, {Key: "debug", Bare: true}, {Key: "c", Value: ""}},
+			nil,
+		},
+		{
+			"a=b =c",
+			Pairs{{Key: "a", Value: "b"}},
+			&SyntaxError{Offset: 4, Reason: reasonEmptyKey},
+		},
+		{
+			`a=b msg="foo bar`,
+			Pairs{{Key: "a", Value: "b"}},
+			&SyntaxError{Offset: 4, Key: "msg", Reason: reasonUnterminatedQuote},
+		},
+		{
+			`a=b msg="foo \q" c=d`,
+			Pairs{{Key: "a", Value: "b"}},
+			&SyntaxError{Offset: 4, Key: "msg", Reason: reasonInvalidQuotedValue},
+		},
+		{
+			`b=1 a=foo"bar" c=2`,
+			Pairs{{Key: "b", Value: "1"}},
+			&SyntaxError{Offset: 4, Key: "a", Reason: reasonQuoteInValue},
+		},
+		{
+			`a="x"b=1`,
+			nil,
+			&SyntaxError{Offset: 0, Key: "a", Reason: reasonMissingSeparator},
+		},
+		{
+			`a="x" b=""`,
+			Pairs{{Key: "a", Value: "x"}, {Key: "b", Value: ""}},
+			nil,
+		},
+	}
+
+	parser := PairParser{Strict: true}
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			pairs, err := parser.Parse(tc.input)
+			require.Equal(t, tc.exp, pairs)
+			if tc.err == nil {
+				require.NoError(t, err)
+			} else {
+				require.Equal(t, tc.err, err)
+			}
+		})
+	}
+}
+
+func TestParseLenientNeverFails(t *testing.T) {
+	var parser PairParser
+
+	pairs, err := parser.Parse(`a=b msg="foo \q" c=d =e`)
+	require.NoError(t, err)
+	require.Equal(t, Pairs{{Key: "a", Value: "b"}, {Key: "msg", Value: ""}, {Key: "c", Value: "d"}, {Key: "", Value: "e"}}, pairs)
+}
+
+func TestSyntaxErrorMessage(t *testing.T) {
+	err := &SyntaxError{Line: 3, Offset: 4, Key: "msg", Reason: reasonUnterminatedQuote}
+	require.Equal(t, `logfmt: syntax error at line 3, offset 4 (key "msg"): unterminated quoted value`, err.Error())
+
+	err = &SyntaxError{Offset: 4, Reason: reasonEmptyKey}
+	require.Equal(t, `logfmt: syntax error at offset 4: empty key`, err.Error())
+}
+
+func TestSplitInvalidUTF8(t *testing.T) {
+	testCases := []struct {
+		input    string
+		preserve Pairs
+		replace  Pairs
+	}{
+		{
+			"a=foo\xffbar b=c",
+			Pairs{{Key: "a", Value: "foo\xffbar"}, {Key: "b", Value: "c"}},
+			Pairs{{Key: "a", Value: "foo\uFFFDbar"}, {Key: "b", Value: "c"}},
+		},
+		{
+			"payload=\x00\x01\xfe\xff",
+			Pairs{{Key: "payload", Value: "\x00\x01\xfe\xff"}},
+			Pairs{{Key: "payload", Value: "\x00\x01\uFFFD\uFFFD"}},
+		},
+		{
+			"payload=\x01\xfe\xff\xc3 next=1",
+			Pairs{{Key: "payload", Value: "\x01\xfe\xff\xc3"}, {Key: "next", Value: "1"}},
+			Pairs{{Key: "payload", Value: "\x01\uFFFD\uFFFD\uFFFD"}, {Key: "next", Value: "1"}},
+		},
+		{
+			"msg=\"caf\xe9 \\xff \\u00e9\" city=Lyon",
+			Pairs{{Key: "msg", Value: "caf\xe9 \xff \u00e9"}, {Key: "city", Value: "Lyon"}},
+			Pairs{{Key: "msg", Value: "caf\uFFFD \xff \u00e9"}, {Key: "city", Value: "Lyon"}},
+		},
+		{
+			"k\xffey=v name=\u00e9t\u00e9 repl=\uFFFD",
+			Pairs{{Key: "k\xffey", Value: "v"}, {Key: "name", Value: "\u00e9t\u00e9"}, {Key: "repl", Value: "\uFFFD"}},
+			Pairs{{Key: "k\uFFFDey", Value: "v"}, {Key: "name", Value: "\u00e9t\u00e9"}, {Key: "repl", Value: "\uFFFD"}},
+		},
+		{
+			"\xffbare a=b",
+			Pairs{{Key: "\xffbare", Bare: true}, {Key: "a", Value: "b"}},
+			Pairs{{Key: "\uFFFDbare", Bare: true}, {Key: "a", Value: "b"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			var parser PairParser
+			require.Equal(t, tc.preserve, parser.Split(tc.input))
+
+			parser.ReplaceInvalidUTF8 = true
+			require.Equal(t, tc.replace, parser.Split(tc.input))
+		})
+	}
+}
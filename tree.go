@@ -0,0 +1,293 @@
+package logfmt
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// KeySeparator separates the levels of a nested key, like in http.req.method.
+const KeySeparator = "."
+
+// Expand converts the pairs into a tree of nested maps by splitting the keys on KeySeparator.
+//
+// For example http.req.method=GET http.req.path=/x gives:
+//
+//	map[string]interface{}{
+//		"http": map[string]interface{}{
+//			"req": map[string]interface{}{"method": "GET", "path": "/x"},
+//		},
+//	}
+//
+// A nested map whose keys are exactly the integers 0 to n-1 becomes a []interface{}, so items.0=a items.1=b gives
+// a slice for "items"; the root is always a map. Values are kept as strings.
+//
+// Like Pairs.Get, if a key appears multiple times only its first value is used. If a key is both a value and a
+// prefix of other keys, like a=1 a.b=2, the pairs which can't be nested are kept in the root map with their full key,
+// whatever the order of the pairs.
+func Expand(pairs Pairs) map[string]interface{} {
+	root := make(map[string]interface{}, len(pairs))
+	for _, pair := range pairs {
+		setPath(root, pair.Key, pair.Value)
+	}
+	convertChildArrays(root)
+	return root
+}
+
+// ExpandMap works like Expand but takes a map of flat keys to arbitrary values.
+// Keys are processed in sorted order.
+func ExpandMap(flat map[string]interface{}) map[string]interface{} {
+	keys := make([]string, 0, len(flat))
+	for k := range flat {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	root := make(map[string]interface{}, len(flat))
+	for _, k := range keys {
+		setPath(root, k, flat[k])
+	}
+	convertChildArrays(root)
+	return root
+}
+
+// setPath sets the value at the path described by key in the tree.
+func setPath(root map[string]interface{}, key string, value interface{}) {
+	path := strings.Split(key, KeySeparator)
+	for _, name := range path {
+		if name == "" {
+			// Can't nest a key like "a..b", keep it as is.
+			path = nil
+			break
+		}
+	}
+
+	cur := root
+	for i, name := range path {
+		next, ok := cur[name]
+
+		if i == len(path)-1 {
+			switch sub, isMap := next.(map[string]interface{}); {
+			case !ok:
+				cur[name] = value
+			case isMap:
+				// The key is a prefix of keys seen before: the value takes their place and they are kept
+				// in the root map with their full key, as if the value had come first.
+				cur[name] = value
+				unnest(root, key, sub)
+			}
+			return
+		}
+
+		if !ok {
+			sub := make(map[string]interface{})
+			cur[name] = sub
+			cur = sub
+			continue
+		}
+
+		sub, ok := next.(map[string]interface{})
+		if !ok {
+			break
+		}
+		cur = sub
+	}
+
+	// The key can't be nested, keep it as is if it's not a duplicate.
+	if _, ok := root[key]; !ok {
+		root[key] = value
+	}
+}
+
+// unnest moves the values of the tree m, found at the path prefix, to the root map with their full key.
+func unnest(root map[string]interface{}, prefix string, m map[string]interface{}) {
+	for k, v := range m {
+		key := prefix + KeySeparator + k
+		if sub, ok := v.(map[string]interface{}); ok {
+			unnest(root, key, sub)
+			continue
+		}
+		if _, ok := root[key]; !ok {
+			root[key] = v
+		}
+	}
+}
+
+func convertChildArrays(m map[string]interface{}) {
+	for k, child := range m {
+		m[k] = convertArrays(child)
+	}
+}
+
+func convertArrays(v interface{}) interface{} {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return v
+	}
+
+	convertChildArrays(m)
+
+	if len(m) == 0 {
+		return m
+	}
+
+	arr := make([]interface{}, len(m))
+	for k, child := range m {
+		i, err := strconv.Atoi(k)
+		if err != nil || i < 0 || i >= len(m) || strconv.Itoa(i) != k {
+			return m
+		}
+		arr[i] = child
+	}
+
+	return arr
+}
+
+// Flatten converts a tree of nested values into pairs with dotted keys; it is the inverse of Expand.
+//
+// v must be a map with string keys or a struct, or a pointer to one of these. Nested maps, structs, slices and
+// arrays are flattened recursively: map keys, struct field names (following the same tag rules as Marshal) and
+// slice indexes are joined with KeySeparator. Map keys are sorted.
+// Other values are formatted like Encoder.EncodeKeyval does. A value containing itself is an error.
+func Flatten(v interface{}) (Pairs, error) {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() {
+		return nil, errors.New("logfmt: Flatten(nil)")
+	}
+	seen := make(map[cycleKey]struct{})
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil, errors.New("logfmt: Flatten(nil)")
+		}
+		if rv.Kind() == reflect.Ptr {
+			seen[cycleKey{ptr: rv.Pointer(), typ: rv.Type()}] = struct{}{}
+		}
+		rv = rv.Elem()
+	}
+
+	switch {
+	case rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String:
+	case rv.Kind() == reflect.Struct:
+	default:
+		return nil, fmt.Errorf("logfmt: Flatten(%s) needs a map or a struct", rv.Type())
+	}
+
+	return flatten(nil, "", rv, seen)
+}
+
+// cycleKey identifies a pointer, a map or a slice on the path being flattened.
+type cycleKey struct {
+	ptr uintptr
+	typ reflect.Type
+	len int
+}
+
+// flatten appends the pairs of v to pairs. seen holds the values on the path from the root to v, to detect cycles.
+func flatten(pairs Pairs, prefix string, v reflect.Value, seen map[cycleKey]struct{}) (Pairs, error) {
+	for v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice:
+		if v.IsNil() {
+			break
+		}
+		key := cycleKey{ptr: v.Pointer(), typ: v.Type()}
+		if v.Kind() == reflect.Slice {
+			key.len = v.Len()
+		}
+		if _, ok := seen[key]; ok {
+			return nil, fmt.Errorf("logfmt: Flatten found a cycle at %q", prefix)
+		}
+		seen[key] = struct{}{}
+		defer delete(seen, key)
+	}
+	if v.Kind() == reflect.Ptr && !v.IsNil() && v.Elem().Kind() == reflect.Struct && !isTextMarshaler(v.Elem()) {
+		v = v.Elem()
+	}
+
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + KeySeparator + key
+	}
+
+	var err error
+
+	switch {
+	case isTextMarshaler(v):
+		// Formatted as a single value below, even if it's a struct like time.Time.
+
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+
+		for _, k := range keys {
+			pairs, err = flatten(pairs, join(k.String()), v.MapIndex(k), seen)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return pairs, nil
+
+	case v.Kind() == reflect.Struct:
+		if !v.CanAddr() {
+			// Make the value addressable so that pointer receivers of encoding.TextMarshaler are found
+			tmp := reflect.New(v.Type()).Elem()
+			tmp.Set(v)
+			v = tmp
+		}
+
+		for _, f := range cachedStructFields(v.Type()) {
+			fv, ok := fieldByIndex(v, f.index, false)
+			if !ok || (f.omitEmpty && fv.IsZero()) {
+				continue
+			}
+			pairs, err = flatten(pairs, join(f.name), fv, seen)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return pairs, nil
+
+	case (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && v.Type().Elem().Kind() != reflect.Uint8:
+		for i := 0; i < v.Len(); i++ {
+			pairs, err = flatten(pairs, join(strconv.Itoa(i)), v.Index(i), seen)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return pairs, nil
+	}
+
+	if !v.IsValid() {
+		return append(pairs, Pair{Key: prefix, Value: "null"}), nil
+	}
+
+	iv := v.Interface()
+	if v.CanAddr() && !v.Type().Implements(textMarshalerType) && v.Addr().Type().Implements(textMarshalerType) {
+		iv = v.Addr().Interface()
+	}
+
+	value, err := formatValue(iv)
+	if err != nil {
+		return nil, fmt.Errorf("logfmt: cannot flatten %q: %w", prefix, err)
+	}
+
+	return append(pairs, Pair{Key: prefix, Value: value}), nil
+}
+
+func isTextMarshaler(v reflect.Value) bool {
+	if !v.IsValid() {
+		return false
+	}
+	if v.Type().Implements(textMarshalerType) {
+		return true
+	}
+	return v.CanAddr() && v.Addr().Type().Implements(textMarshalerType)
+}
@@ -0,0 +1,211 @@
+package logfmt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpand(t *testing.T) {
+	testCases := []struct {
+		input string
+		exp   map[string]interface{}
+	}{
+		{
+			`a=1 b=2`,
+			map[string]interface{}{"a": "1", "b": "2"},
+		},
+		{
+			`http.req.method=GET http.req.path=/x http.status=200`,
+			map[string]interface{}{
+				"http": map[string]interface{}{
+					"req":    map[string]interface{}{"method": "GET", "path": "/x"},
+					"status": "200",
+				},
+			},
+		},
+		{
+			`items.1=b items.0=a`,
+			map[string]interface{}{"items": []interface{}{"a", "b"}},
+		},
+		{
+			`items.0.id=1 items.1.id=2`,
+			map[string]interface{}{
+				"items": []interface{}{
+					map[string]interface{}{"id": "1"},
+					map[string]interface{}{"id": "2"},
+				},
+			},
+		},
+		{
+			`items.0=a items.2=c`,
+			map[string]interface{}{"items": map[string]interface{}{"0": "a", "2": "c"}},
+		},
+		{
+			`a=1 a=2`,
+			map[string]interface{}{"a": "1"},
+		},
+		{
+			`a=1 a.b=2`,
+			map[string]interface{}{"a": "1", "a.b": "2"},
+		},
+		{
+			`a.b=2 a=1`,
+			map[string]interface{}{"a": "1", "a.b": "2"},
+		},
+		{
+			`a.b.c=1 a.b=2 a=0`,
+			map[string]interface{}{"a": "0", "a.b": "2", "a.b.c": "1"},
+		},
+		{
+			`a.b=1 a.b=2 a.c=3`,
+			map[string]interface{}{"a": map[string]interface{}{"b": "1", "c": "3"}},
+		},
+		{
+			`a..b=1 .c=2`,
+			map[string]interface{}{"a..b": "1", ".c": "2"},
+		},
+		{
+			`0=a 1=b`,
+			map[string]interface{}{"0": "a", "1": "b"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			require.Equal(t, tc.exp, Expand(Split(tc.input)))
+		})
+	}
+}
+
+func TestExpandMap(t *testing.T) {
+	res := ExpandMap(map[string]interface{}{
+		"a.b":   1,
+		"a.c.0": true,
+		"a":     "conflict",
+	})
+	require.Equal(t, map[string]interface{}{
+		"a":     "conflict",
+		"a.b":   1,
+		"a.c.0": true,
+	}, res)
+
+	res = ExpandMap(map[string]interface{}{"0": "a", "1.x": "b"})
+	require.Equal(t, map[string]interface{}{
+		"0": "a",
+		"1": map[string]interface{}{"x": "b"},
+	}, res)
+}
+
+func TestFlatten(t *testing.T) {
+	type Request struct {
+		Method string `logfmt:"method"`
+		Path   string `logfmt:"path,omitempty"`
+	}
+	type Entry struct {
+		Request  *Request          `logfmt:"req"`
+		Tags     []string          `logfmt:"tags"`
+		Labels   map[string]string `logfmt:"labels"`
+		Time     time.Time         `logfmt:"time"`
+		Ignored  string            `logfmt:"-"`
+		Duration time.Duration
+	}
+
+	testCases := []struct {
+		input interface{}
+		exp   Pairs
+	}{
+		{
+			map[string]interface{}{
+				"b": "2",
+				"a": map[string]interface{}{"y": 1, "x": []interface{}{"a", nil}},
+			},
+			Pairs{
+				{Key: "a.x.0", Value: "a"},
+				{Key: "a.x.1", Value: "null"},
+				{Key: "a.y", Value: "1"},
+				{Key: "b", Value: "2"},
+			},
+		},
+		{
+			&Entry{
+				Request:  &Request{Method: "GET"},
+				Tags:     []string{"x", "y"},
+				Labels:   map[string]string{"env": "prod"},
+				Time:     time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC),
+				Ignored:  "foo",
+				Duration: time.Second,
+			},
+			Pairs{
+				{Key: "req.method", Value: "GET"},
+				{Key: "tags.0", Value: "x"},
+				{Key: "tags.1", Value: "y"},
+				{Key: "labels.env", Value: "prod"},
+				{Key: "time", Value: "2026-10-17T12:00:00Z"},
+				{Key: "Duration", Value: "1s"},
+			},
+		},
+		{
+			Entry{},
+			Pairs{
+				{Key: "req", Value: "null"},
+				{Key: "time", Value: "0001-01-01T00:00:00Z"},
+				{Key: "Duration", Value: "0s"},
+			},
+		},
+		{
+			map[string][]byte{"data": []byte("hello")},
+			Pairs{{Key: "data", Value: "hello"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			pairs, err := Flatten(tc.input)
+			require.NoError(t, err)
+			require.Equal(t, tc.exp, pairs)
+		})
+	}
+
+	_, err := Flatten("foobar")
+	require.Error(t, err)
+	_, err = Flatten(nil)
+	require.Error(t, err)
+}
+
+func TestFlattenCycles(t *testing.T) {
+	type Node struct {
+		Name string `logfmt:"name"`
+		Next *Node  `logfmt:"next"`
+	}
+
+	n := &Node{Name: "a"}
+	n.Next = n
+	_, err := Flatten(n)
+	require.EqualError(t, err, `logfmt: Flatten found a cycle at "next"`)
+
+	m := map[string]interface{}{"a": 1}
+	m["self"] = m
+	_, err = Flatten(m)
+	require.EqualError(t, err, `logfmt: Flatten found a cycle at "self"`)
+
+	// The same value twice is not a cycle
+	shared := &Node{Name: "b"}
+	pairs, err := Flatten(map[string]interface{}{"x": shared, "y": shared})
+	require.NoError(t, err)
+	require.Equal(t, Pairs{
+		{Key: "x.name", Value: "b"},
+		{Key: "x.next", Value: "null"},
+		{Key: "y.name", Value: "b"},
+		{Key: "y.next", Value: "null"},
+	}, pairs)
+}
+
+func TestExpandFlattenRoundTrip(t *testing.T) {
+	pairs := Split(`a.b=1 a.c.0=x a.c.1=y d=2`)
+
+	res, err := Flatten(Expand(pairs))
+	require.NoError(t, err)
+	require.Equal(t, pairs, res)
+}
@@ -0,0 +1,237 @@
+package logfmt
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrKeyNotFound is returned by the typed accessors of Pairs when the key is not present.
+var ErrKeyNotFound = errors.New("logfmt: key not found")
+
+// DefaultTimeLayouts are the layouts tried by Pair.Time when none are given.
+var DefaultTimeLayouts = []string{time.RFC3339Nano}
+
+// EpochLayout is a layout for Pair.Time parsing a number of seconds or milliseconds since the Unix epoch,
+// like 1760702401, 1760702401.5 or 1760702401500.
+//
+// Numbers from 1e11 are milliseconds: as seconds they would be after the year 5000.
+const EpochLayout = "epoch"
+
+const epochMillisThreshold = 1e11
+
+// Int parses the value as a base 10 integer.
+func (p Pair) Int() (int64, error) {
+	n, err := strconv.ParseInt(p.Value, 10, 64)
+	return n, p.wrapErr(err)
+}
+
+// Float parses the value as a floating point number.
+func (p Pair) Float() (float64, error) {
+	f, err := strconv.ParseFloat(p.Value, 64)
+	return f, p.wrapErr(err)
+}
+
+// Bool parses the value as a boolean with strconv.ParseBool.
+// A bare key is true.
+func (p Pair) Bool() (bool, error) {
+	if p.Bare {
+		return true, nil
+	}
+	b, err := strconv.ParseBool(p.Value)
+	return b, p.wrapErr(err)
+}
+
+// Duration parses the value as a duration with time.ParseDuration.
+func (p Pair) Duration() (time.Duration, error) {
+	d, err := time.ParseDuration(p.Value)
+	return d, p.wrapErr(err)
+}
+
+// Time parses the value as a time, trying each layout in order until one succeeds.
+// If no layouts are given DefaultTimeLayouts is used.
+func (p Pair) Time(layouts ...string) (time.Time, error) {
+	if len(layouts) == 0 {
+		layouts = DefaultTimeLayouts
+	}
+
+	var err error
+	for _, layout := range layouts {
+		var t time.Time
+		if layout == EpochLayout {
+			t, err = parseEpoch(p.Value)
+		} else {
+			t, err = time.Parse(layout, p.Value)
+		}
+		if err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, p.wrapErr(err)
+}
+
+// Bytes parses the value as a human readable size in bytes, like 512, 10KB, 1.5MiB or 2g.
+//
+// Units are case insensitive. SI units (k, KB, M, MB, ...) are powers of 1000 while
+// IEC units (Ki, KiB, Mi, MiB, ...) are powers of 1024. The trailing B is optional.
+func (p Pair) Bytes() (int64, error) {
+	n, err := parseBytes(p.Value)
+	return n, p.wrapErr(err)
+}
+
+func parseEpoch(s string) (time.Time, error) {
+	integer, fraction, _ := strings.Cut(s, ".")
+
+	n, err := strconv.ParseInt(integer, 10, 64)
+	if err != nil || strings.Trim(fraction, "0123456789") != "" {
+		return time.Time{}, fmt.Errorf("invalid epoch time %q", s)
+	}
+
+	// The fraction is a number of nanoseconds for seconds and of nanoseconds * 1e-6 for milliseconds
+	digits := 9
+	if n >= epochMillisThreshold || n <= -epochMillisThreshold {
+		digits = 6
+	}
+	if len(fraction) > digits {
+		fraction = fraction[:digits]
+	}
+	var nsec int64
+	if fraction != "" {
+		nsec, _ = strconv.ParseInt(fraction+strings.Repeat("0", digits-len(fraction)), 10, 64)
+		if n < 0 || integer == "-0" {
+			nsec = -nsec
+		}
+	}
+
+	if digits == 6 {
+		return time.UnixMilli(n).Add(time.Duration(nsec)).UTC(), nil
+	}
+	return time.Unix(n, nsec).UTC(), nil
+}
+
+func (p Pair) wrapErr(err error) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("logfmt: key %q: %w", p.Key, err)
+}
+
+var byteUnits = map[string]float64{
+	"":  1,
+	"k": 1e3,
+	"m": 1e6,
+	"g": 1e9,
+	"t": 1e12,
+	"p": 1e15,
+	"e": 1e18,
+
+	"ki": 1 << 10,
+	"mi": 1 << 20,
+	"gi": 1 << 30,
+	"ti": 1 << 40,
+	"pi": 1 << 50,
+	"ei": 1 << 60,
+}
+
+func parseBytes(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+
+	pos := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if pos == -1 {
+		pos = len(s)
+	}
+
+	number, unit := s[:pos], strings.ToLower(strings.TrimSpace(s[pos:]))
+	unit = strings.TrimSuffix(unit, "b")
+
+	multiplier, ok := byteUnits[unit]
+	if !ok || number == "" {
+		return 0, fmt.Errorf("invalid byte size %q", s)
+	}
+
+	f, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid byte size %q", s)
+	}
+
+	res := f * multiplier
+	if res >= math.MaxInt64 {
+		return 0, fmt.Errorf("byte size %q out of range", s)
+	}
+
+	return int64(res), nil
+}
+
+// Int parses the value of the first pair with the key as a base 10 integer.
+// It returns an error wrapping ErrKeyNotFound if there is no such pair.
+func (p Pairs) Int(key string) (int64, error) {
+	pair, err := p.find(key)
+	if err != nil {
+		return 0, err
+	}
+	return pair.Int()
+}
+
+// Float parses the value of the first pair with the key as a floating point number.
+// It returns an error wrapping ErrKeyNotFound if there is no such pair.
+func (p Pairs) Float(key string) (float64, error) {
+	pair, err := p.find(key)
+	if err != nil {
+		return 0, err
+	}
+	return pair.Float()
+}
+
+// Bool parses the value of the first pair with the key as a boolean.
+// It returns an error wrapping ErrKeyNotFound if there is no such pair.
+func (p Pairs) Bool(key string) (bool, error) {
+	pair, err := p.find(key)
+	if err != nil {
+		return false, err
+	}
+	return pair.Bool()
+}
+
+// Duration parses the value of the first pair with the key as a duration.
+// It returns an error wrapping ErrKeyNotFound if there is no such pair.
+func (p Pairs) Duration(key string) (time.Duration, error) {
+	pair, err := p.find(key)
+	if err != nil {
+		return 0, err
+	}
+	return pair.Duration()
+}
+
+// Time parses the value of the first pair with the key as a time, see Pair.Time.
+// It returns an error wrapping ErrKeyNotFound if there is no such pair.
+func (p Pairs) Time(key string, layouts ...string) (time.Time, error) {
+	pair, err := p.find(key)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return pair.Time(layouts...)
+}
+
+// Bytes parses the value of the first pair with the key as a human readable size, see Pair.Bytes.
+// It returns an error wrapping ErrKeyNotFound if there is no such pair.
+func (p Pairs) Bytes(key string) (int64, error) {
+	pair, err := p.find(key)
+	if err != nil {
+		return 0, err
+	}
+	return pair.Bytes()
+}
+
+func (p Pairs) find(key string) (Pair, error) {
+	i := p.Index(key)
+	if i == -1 {
+		return Pair{}, fmt.Errorf("%w: %q", ErrKeyNotFound, key)
+	}
+	return p[i], nil
+}
@@ -0,0 +1,123 @@
+package logfmt
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPairAccessors(t *testing.T) {
+	pairs := Split(`status=200 ratio=0.25 cached=true retry elapsed=1m30s time=2026-10-17T12:00:01.5Z date=2026-10-17 size=1.5MiB name=vincent`)
+
+	n, err := pairs.Int("status")
+	require.NoError(t, err)
+	require.Equal(t, int64(200), n)
+
+	f, err := pairs.Float("ratio")
+	require.NoError(t, err)
+	require.Equal(t, 0.25, f)
+
+	b, err := pairs.Bool("cached")
+	require.NoError(t, err)
+	require.True(t, b)
+
+	b, err = pairs.Bool("retry")
+	require.NoError(t, err)
+	require.True(t, b)
+
+	d, err := pairs.Duration("elapsed")
+	require.NoError(t, err)
+	require.Equal(t, 90*time.Second, d)
+
+	tm, err := pairs.Time("time")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2026, 10, 17, 12, 0, 1, 500000000, time.UTC), tm)
+
+	tm, err = pairs.Time("date", time.RFC3339, "2006-01-02")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC), tm)
+
+	size, err := pairs.Bytes("size")
+	require.NoError(t, err)
+	require.Equal(t, int64(1572864), size)
+
+	_, err = pairs.Int("name")
+	require.EqualError(t, err, `logfmt: key "name": strconv.ParseInt: parsing "vincent": invalid syntax`)
+
+	_, err = pairs.Duration("nope")
+	require.True(t, errors.Is(err, ErrKeyNotFound))
+
+	_, err = pairs.Time("date")
+	require.Error(t, err)
+}
+
+func TestPairTimeEpoch(t *testing.T) {
+	testCases := []struct {
+		input string
+		exp   time.Time
+		err   bool
+	}{
+		{"1760702401", time.Date(2025, 10, 17, 12, 0, 1, 0, time.UTC), false},
+		{"1760702401.5", time.Date(2025, 10, 17, 12, 0, 1, 500000000, time.UTC), false},
+		{"1760702401.000000001", time.Date(2025, 10, 17, 12, 0, 1, 1, time.UTC), false},
+		{"1760702401500", time.Date(2025, 10, 17, 12, 0, 1, 500000000, time.UTC), false},
+		{"1760702401500.25", time.Date(2025, 10, 17, 12, 0, 1, 500250000, time.UTC), false},
+		{"0", time.Unix(0, 0).UTC(), false},
+		{"-1.5", time.Unix(-2, 500000000).UTC(), false},
+		{"", time.Time{}, true},
+		{"1760702401.5s", time.Time{}, true},
+		{"2026-10-17T12:00:01Z", time.Time{}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			tm, err := Pair{Key: "ts", Value: tc.input}.Time(EpochLayout)
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.exp, tm)
+		})
+	}
+}
+
+func TestPairBytes(t *testing.T) {
+	testCases := []struct {
+		input string
+		exp   int64
+		err   bool
+	}{
+		{"512", 512, false},
+		{"512B", 512, false},
+		{"10KB", 10000, false},
+		{"10k", 10000, false},
+		{"10KiB", 10240, false},
+		{"10Ki", 10240, false},
+		{"1.5MiB", 1572864, false},
+		{"2g", 2000000000, false},
+		{"1 GiB", 1 << 30, false},
+		{"3TB", 3e12, false},
+		{"", 0, true},
+		{"KB", 0, true},
+		{"10XB", 0, true},
+		{"1.2.3MB", 0, true},
+		{"7EiB", 7 << 60, false},
+		{"8EiB", 0, true},
+		{"20EiB", 0, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			n, err := Pair{Key: "size", Value: tc.input}.Bytes()
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.exp, n)
+		})
+	}
+}